@@ -0,0 +1,224 @@
+// Package boot ties the env and server packages together: it loads
+// the app config from the environment, validates it, builds the server
+// and reports everything resolved at startup as one JSON document.
+//
+// Typical usage:
+//
+//	var cfg struct {
+//	    HTTP boot.ServerConfig `env:"HTTP_"`
+//	    DB   struct {
+//	        DSN string `env:"DSN,required,secret"`
+//	    } `env:"DB_"`
+//	}
+//	report, err := boot.Load(&cfg)
+//	if err != nil {
+//	    report.WriteJSON(os.Stderr)
+//	    log.Fatal(err)
+//	}
+//	srv := cfg.HTTP.NewServer(handler)
+//	report.AddServer(srv)
+//	report.WriteJSON(os.Stderr)
+//	os.Exit(server.ExitCode(srv.Run(ctx)))
+package boot
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/hypnoglow/x/env"
+	"github.com/hypnoglow/x/server"
+)
+
+// Validator is implemented by configs that check themselves
+// after they are loaded, e.g. for constraints between fields.
+type Validator interface {
+	Validate() error
+}
+
+// ServerConfig is the server configuration declared for the env package.
+// Embed it into the app config, usually with a prefix.
+type ServerConfig struct {
+	Addr              string        `env:"ADDR,default=:8080"`
+	ReadTimeout       time.Duration `env:"READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `env:"READ_HEADER_TIMEOUT,default=10s"`
+	WriteTimeout      time.Duration `env:"WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `env:"IDLE_TIMEOUT,default=2m"`
+	ShutdownTimeout   time.Duration `env:"SHUTDOWN_TIMEOUT,default=10s"`
+	DrainDelay        time.Duration `env:"DRAIN_DELAY"`
+	MaxConnections    int           `env:"MAX_CONNECTIONS"`
+	AdminAddr         string        `env:"ADMIN_ADDR"`
+	Health            bool          `env:"HEALTH,default=true"`
+}
+
+// Validate implements Validator.
+func (c ServerConfig) Validate() error {
+	if c.MaxConnections < 0 {
+		return errors.New("max connections must not be negative")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return errors.New("shutdown timeout must be positive")
+	}
+	return nil
+}
+
+// NewServer returns a server configured by c. The options are applied
+// after the ones derived from c, so they take precedence.
+func (c ServerConfig) NewServer(handler http.Handler, opts ...server.Option) *server.Server {
+	hs := &http.Server{
+		Addr:              c.Addr,
+		Handler:           handler,
+		ReadTimeout:       c.ReadTimeout,
+		ReadHeaderTimeout: c.ReadHeaderTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+	}
+
+	base := []server.Option{
+		server.ShutdownTimeout(c.ShutdownTimeout),
+		server.DrainDelay(c.DrainDelay),
+		server.MaxConnections(c.MaxConnections),
+	}
+	if c.AdminAddr != "" {
+		base = append(base, server.Admin(c.AdminAddr))
+	}
+	if c.Health {
+		base = append(base, server.Health())
+	}
+	return server.Wrap(hs, append(base, opts...)...)
+}
+
+// Report is everything resolved at startup.
+type Report struct {
+	Time    time.Time       `json:"time"`
+	Profile env.Environment `json:"profile"`
+
+	// Variables are the variables declared by the config, in field order.
+	Variables []Variable `json:"variables"`
+
+	// Errors are the problems found while loading and validating.
+	Errors []string `json:"errors,omitempty"`
+
+	// Server is the effective server config, see AddServer.
+	Server *server.Config `json:"server,omitempty"`
+}
+
+// Variable is a resolved environment variable.
+type Variable struct {
+	Name string `json:"name"`
+
+	// Value is the loaded value, masked if the field has the secret option.
+	Value string `json:"value"`
+
+	// Source is where the value came from, empty if the variable is unset.
+	Source string `json:"source,omitempty"`
+}
+
+const mask = "******"
+
+// Load loads the config pointed to by cfg with env.Load and validates it
+// with its Validate method and the ones of its nested structs, if any.
+// It returns the report of the resolved config, even if loading fails,
+// so the report can be written for the operator along with the error.
+//
+// Fields with the secret option in the env tag, which must go before
+// the default option, are masked in the report:
+//
+//	Password string `env:"PASSWORD,required,secret"`
+//
+// Load enables env.TrackProvenance for the whole process, to report
+// the source of every value, and leaves it enabled. The provenance
+// recorded before Load is reset.
+func Load(cfg interface{}) (*Report, error) {
+	env.TrackProvenance(true)
+
+	report := &Report{
+		Time:    time.Now(),
+		Profile: env.Profile(),
+	}
+
+	var errs []error
+	if err := env.Load(cfg); err != nil {
+		var verrs env.Errors
+		if errors.As(err, &verrs) {
+			for _, verr := range verrs {
+				errs = append(errs, verr)
+			}
+		} else {
+			return report, err
+		}
+	}
+
+	report.Variables = variables(cfg)
+	if len(errs) == 0 {
+		errs = validate(reflect.ValueOf(cfg).Elem())
+	}
+
+	for _, err := range errs {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	return report, errors.Join(errs...)
+}
+
+// AddServer adds the effective config of the server to the report.
+func (r *Report) AddServer(srv *server.Server) {
+	c := srv.Config()
+	r.Server = &c
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// variables returns the variables declared by cfg with the values
+// and sources recorded by env while loading it.
+func variables(cfg interface{}) []Variable {
+	decls, err := env.Declarations(cfg)
+	if err != nil {
+		return nil
+	}
+
+	provenance := env.Provenance()
+	vars := make([]Variable, 0, len(decls))
+	for _, d := range decls {
+		v := Variable{Name: d.Name}
+		if o, ok := provenance[d.Name]; ok {
+			v.Source = o.String()
+			v.Value = os.Getenv(d.Name)
+			if o.Source == env.SourceDefault {
+				v.Value = d.DefaultFor(env.Profile())
+			}
+			if d.Secret {
+				v.Value = mask
+			}
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// validate calls Validate of the struct and its nested structs.
+// Unlike env.Load, it visits struct fields loaded as single values too,
+// e.g. time.Time, since any of them may implement Validator.
+func validate(rv reflect.Value) []error {
+	var errs []error
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if fv := rv.Field(i); fv.CanSet() && fv.Kind() == reflect.Struct {
+			errs = append(errs, validate(fv)...)
+		}
+	}
+	if v, ok := rv.Addr().Interface().(Validator); ok {
+		if err := v.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
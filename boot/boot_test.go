@@ -0,0 +1,116 @@
+package boot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	HTTP ServerConfig `env:"HTTP_"`
+	DB   struct {
+		DSN string `env:"DSN,required,secret"`
+	} `env:"DB_"`
+	Workers int       `env:"WORKERS,default=4"`
+	Since   time.Time `env:"SINCE,default=2024-01-02T03:04:05Z"`
+}
+
+func (c testConfig) Validate() error {
+	if c.Workers > 100 {
+		return errors.New("too many workers")
+	}
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("HTTP_ADDR", "127.0.0.1:0")
+		os.Setenv("HTTP_MAX_CONNECTIONS", "100")
+		os.Setenv("DB_DSN", "postgres://user:password@db/app")
+
+		var cfg testConfig
+		report, err := Load(&cfg)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if cfg.HTTP.Addr != "127.0.0.1:0" || cfg.HTTP.ShutdownTimeout != time.Second*10 || cfg.Workers != 4 {
+			t.Fatalf("Unexpected config: %+v", cfg)
+		}
+
+		srv := cfg.HTTP.NewServer(http.NotFoundHandler())
+		if c := srv.Config(); c.MaxConnections != 100 || !c.Health || c.ReadHeaderTimeout != time.Second*10 {
+			t.Fatalf("Unexpected server config: %+v", c)
+		}
+		report.AddServer(srv)
+
+		var buf bytes.Buffer
+		if err := report.WriteJSON(&buf); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if strings.Contains(buf.String(), "password") {
+			t.Fatalf("Expected secret to be masked but got %s", buf.String())
+		}
+
+		var decoded Report
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		vars := make(map[string]Variable)
+		for _, v := range decoded.Variables {
+			vars[v.Name] = v
+		}
+		if v := vars["HTTP_ADDR"]; v.Value != "127.0.0.1:0" || v.Source != "env" {
+			t.Fatalf("Unexpected HTTP_ADDR: %+v", v)
+		}
+		if v := vars["WORKERS"]; v.Value != "4" || v.Source != "default" {
+			t.Fatalf("Unexpected WORKERS: %+v", v)
+		}
+		if v := vars["SINCE"]; v.Value != "2024-01-02T03:04:05Z" || v.Source != "default" {
+			t.Fatalf("Unexpected SINCE: %+v", v)
+		}
+		if v := vars["DB_DSN"]; v.Value != mask {
+			t.Fatalf("Unexpected DB_DSN: %+v", v)
+		}
+		if v := vars["HTTP_ADMIN_ADDR"]; v.Value != "" || v.Source != "" {
+			t.Fatalf("Expected HTTP_ADMIN_ADDR to be unset but got %+v", v)
+		}
+		if decoded.Server == nil || decoded.Server.MaxConnections != 100 || decoded.Profile != "dev" {
+			t.Fatalf("Unexpected report: %+v", decoded)
+		}
+	})
+
+	t.Run("reports missing variables", func(t *testing.T) {
+		os.Clearenv()
+
+		var cfg testConfig
+		report, err := Load(&cfg)
+		if err == nil {
+			t.Fatalf("Expected error")
+		}
+		if len(report.Errors) != 1 || !strings.Contains(report.Errors[0], "DB_DSN") {
+			t.Fatalf("Unexpected report errors: %v", report.Errors)
+		}
+	})
+
+	t.Run("reports validation errors", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_DSN", "postgres://db/app")
+		os.Setenv("WORKERS", "1000")
+		os.Setenv("HTTP_MAX_CONNECTIONS", "-1")
+
+		var cfg testConfig
+		report, err := Load(&cfg)
+		if err == nil {
+			t.Fatalf("Expected error")
+		}
+		if len(report.Errors) != 2 {
+			t.Fatalf("Expected 2 validation errors but got %v", report.Errors)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Validator checks the value of an environment variable.
+type Validator func(value string) error
+
+// NonEmpty returns a validator that fails on empty values.
+func NonEmpty() Validator {
+	return func(value string) error {
+		if value == "" {
+			return errors.New("must not be empty")
+		}
+		return nil
+	}
+}
+
+// OneOf returns a validator that fails on values not in the list.
+func OneOf(values ...string) Validator {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s], %s given", strings.Join(values, ", "), value)
+	}
+}
+
+// MatchesRegexp returns a validator that fails on values
+// not matching the regular expression.
+// It panics if the expression cannot be parsed.
+func MatchesRegexp(expr string) Validator {
+	re := regexp.MustCompile(expr)
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("must match %s, %s given", expr, value)
+		}
+		return nil
+	}
+}
+
+// Requirement describes a required environment variable.
+type Requirement struct {
+	Variable   string
+	Validators []Validator
+}
+
+// Var returns a requirement for the variable to be present
+// and to pass all the validators.
+func Var(variable string, validators ...Validator) Requirement {
+	return Requirement{Variable: variable, Validators: validators}
+}
+
+// Require checks that all the variables are present in the environment.
+// It returns Errors listing every missing variable, so all of them
+// can be reported at once on startup.
+func Require(variables ...string) error {
+	reqs := make([]Requirement, len(variables))
+	for i, variable := range variables {
+		reqs[i] = Var(variable)
+	}
+	return Check(reqs...)
+}
+
+// Check checks all the requirements. It returns Errors listing
+// every missing variable and every validation failure:
+//
+//	err := env.Check(
+//	    env.Var("DATABASE_URL", env.NonEmpty()),
+//	    env.Var("LOG_LEVEL", env.OneOf("debug", "info", "error")),
+//	)
+func Check(reqs ...Requirement) error {
+	var errs Errors
+	for _, req := range reqs {
+		variable := strings.TrimPrefix(req.Variable, "$")
+		value, ok := os.LookupEnv(variable)
+		if !ok {
+			errs = append(errs, &VariableError{Variable: variable, Err: ErrMissing})
+			continue
+		}
+
+		for _, validate := range req.Validators {
+			if err := validate(value); err != nil {
+				errs = append(errs, &VariableError{Variable: variable, Err: err})
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestRequire(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("A", "")
+		os.Setenv("B", "b")
+
+		if err := Require("A", "$B"); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	})
+
+	t.Run("reports all missing variables", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("B", "b")
+
+		err := Require("A", "B", "C")
+
+		var errs Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("Expected Errors but got %v", err)
+		}
+		if len(errs) != 2 || errs[0].Variable != "A" || errs[1].Variable != "C" {
+			t.Fatalf("Unexpected errors: %v", err)
+		}
+	})
+}
+
+func TestCheck(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("EMPTY", "")
+	os.Setenv("LEVEL", "trace")
+	os.Setenv("PORT", "80a")
+	os.Setenv("OK", "debug")
+
+	err := Check(
+		Var("EMPTY", NonEmpty()),
+		Var("LEVEL", OneOf("debug", "info")),
+		Var("PORT", MatchesRegexp(`^\d+$`)),
+		Var("OK", NonEmpty(), OneOf("debug", "info")),
+	)
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected Errors but got %v", err)
+	}
+
+	expected := "variable EMPTY: must not be empty; " +
+		"variable LEVEL: must be one of [debug, info], trace given; " +
+		`variable PORT: must match ^\d+$, 80a given`
+	if err.Error() != expected {
+		t.Fatalf("Expected error to be %q but got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,133 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoadFile loads variables from the dotenv files into the environment.
+// Variables already present in the environment are not overridden,
+// so the process environment always wins over the files,
+// and earlier files win over later ones.
+//
+// The files consist of lines in the form:
+//
+//	# comment
+//	export KEY=value
+//	KEY=value # comment
+//	KEY="value with \"escapes\"\n and ${EXPANSION}"
+//	KEY='literal value, ${NOT_EXPANDED}'
+//
+// Unquoted and double-quoted values are expanded with ExpandAll, so they can
+// reference variables defined earlier in the files or in the environment.
+func LoadFile(filenames ...string) error {
+	return loadFiles(filenames, false)
+}
+
+// Overload is like LoadFile, but variables from the files
+// override the ones already present in the environment.
+func Overload(filenames ...string) error {
+	return loadFiles(filenames, true)
+}
+
+func loadFiles(filenames []string, override bool) error {
+	for _, filename := range filenames {
+		if err := loadFile(filename, override); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadFile(filename string, override bool) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return parseDotenv(f, func(key, value string) {
+		if _, ok := os.LookupEnv(key); ok && !override {
+			return
+		}
+		os.Setenv(key, value)
+		recordFile(key, value, filename)
+	}, filename)
+}
+
+// parseDotenv parses dotenv content from r and calls set for every variable.
+func parseDotenv(r io.Reader, set func(key, value string), filename string) error {
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return fmt.Errorf("%s:%d: expected KEY=value, got %q", filename, n, line)
+		}
+		key := strings.TrimSpace(line[:i])
+		if key == "" {
+			return fmt.Errorf("%s:%d: empty variable name", filename, n)
+		}
+
+		value, err := parseDotenvValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s", filename, n, err)
+		}
+		set(key, value)
+	}
+	return scanner.Err()
+}
+
+func parseDotenvValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+
+	case strings.HasPrefix(raw, `"`):
+		// Text is expanded in segments between escaped dollar signs,
+		// so that \$VAR stays literal.
+		var out, b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch c := raw[i]; {
+			case c == '"':
+				out.WriteString(ExpandAll(b.String()))
+				return out.String(), nil
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case '$':
+					out.WriteString(ExpandAll(b.String()))
+					out.WriteByte('$')
+					b.Reset()
+				default:
+					b.WriteByte(raw[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = strings.TrimSpace(raw[:i])
+		}
+		return ExpandAll(raw), nil
+	}
+}
@@ -0,0 +1,81 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, ".env")
+	content := `# comment
+export HOST=localhost
+PORT=8080 # inline comment
+ADDR=${HOST}:${PORT}
+GREETING="hello\n\"${USER_NAME:-world}\""
+LITERAL='${HOST} # not a comment'
+ESCAPED="\$HOME at ${HOST}"
+EXISTING=from-file
+`
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("EXISTING", "from-env")
+
+		if err := LoadFile(filename); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := map[string]string{
+			"HOST":     "localhost",
+			"PORT":     "8080",
+			"ADDR":     "localhost:8080",
+			"GREETING": "hello\n\"world\"",
+			"LITERAL":  "${HOST} # not a comment",
+			"ESCAPED":  "$HOME at localhost",
+			"EXISTING": "from-env",
+		}
+		for key, value := range expected {
+			if got := os.Getenv(key); got != value {
+				t.Fatalf("Expected %s to be %q but got %q", key, value, got)
+			}
+		}
+	})
+
+	t.Run("ok with override", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("EXISTING", "from-env")
+
+		if err := Overload(filename); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if got := os.Getenv("EXISTING"); got != "from-file" {
+			t.Fatalf("Expected EXISTING to be %q but got %q", "from-file", got)
+		}
+	})
+
+	t.Run("fails on malformed line", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.env")
+		ioutil.WriteFile(bad, []byte("A=1\nMALFORMED\n"), 0600)
+
+		err := LoadFile(bad)
+		if err == nil {
+			t.Fatalf("Expected error")
+		}
+		if expected := bad + `:2: expected KEY=value, got "MALFORMED"`; err.Error() != expected {
+			t.Fatalf("Expected error to be %q but got %q", expected, err.Error())
+		}
+	})
+
+	t.Run("fails on missing file", func(t *testing.T) {
+		if err := LoadFile(filepath.Join(dir, "missing.env")); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
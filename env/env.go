@@ -4,8 +4,11 @@ package env
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Must returns the value of the environment variable.
@@ -18,6 +21,7 @@ func Must(variable string) string {
 	if !ok {
 		panic(fmt.Sprintf("variable %s is not present in the environment", variable))
 	}
+	recordEnv(variable)
 	return value
 }
 
@@ -41,8 +45,10 @@ func Get(variable, defaultValue string) string {
 	variable = strings.TrimPrefix(variable, "$")
 	value := os.Getenv(variable)
 	if value == "" {
+		recordDefault(variable)
 		return defaultValue
 	}
+	recordEnv(variable)
 	return value
 }
 
@@ -54,10 +60,164 @@ func Bool(variable string, defaultValue bool) bool {
 	value := os.Getenv(variable)
 	switch value {
 	case "true":
+		recordEnv(variable)
 		return true
 	case "false":
+		recordEnv(variable)
 		return false
 	default:
+		recordDefault(variable)
 		return defaultValue
 	}
 }
+
+// MustInt returns int value of the environment variable.
+// It panics if variable is not present, or if value is not a valid integer.
+func MustInt(variable string) int {
+	value := Must(variable)
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Sprintf("environment variable %s must be an integer, %s given", variable, value))
+	}
+	return i
+}
+
+// Int returns int value of the environment variable.
+// If the variable is not present, is empty or is not a valid integer,
+// returns defaultValue.
+func Int(variable string, defaultValue int) int {
+	i, err := strconv.Atoi(Get(variable, ""))
+	if err != nil {
+		recordDefault(variable)
+		return defaultValue
+	}
+	return i
+}
+
+// MustFloat64 returns float64 value of the environment variable.
+// It panics if variable is not present, or if value is not a valid float.
+func MustFloat64(variable string) float64 {
+	value := Must(variable)
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		panic(fmt.Sprintf("environment variable %s must be a float, %s given", variable, value))
+	}
+	return f
+}
+
+// Float64 returns float64 value of the environment variable.
+// If the variable is not present, is empty or is not a valid float,
+// returns defaultValue.
+func Float64(variable string, defaultValue float64) float64 {
+	f, err := strconv.ParseFloat(Get(variable, ""), 64)
+	if err != nil {
+		recordDefault(variable)
+		return defaultValue
+	}
+	return f
+}
+
+// MustDuration returns time.Duration value of the environment variable,
+// parsed with time.ParseDuration.
+// It panics if variable is not present, or if value is not a valid duration.
+func MustDuration(variable string) time.Duration {
+	value := Must(variable)
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		panic(fmt.Sprintf("environment variable %s must be a duration, %s given", variable, value))
+	}
+	return d
+}
+
+// Duration returns time.Duration value of the environment variable,
+// parsed with time.ParseDuration.
+// If the variable is not present, is empty or is not a valid duration,
+// returns defaultValue.
+func Duration(variable string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(Get(variable, ""))
+	if err != nil {
+		recordDefault(variable)
+		return defaultValue
+	}
+	return d
+}
+
+// MustDurationSeconds is like MustDuration,
+// but interprets a bare integer value as a number of seconds:
+// "30" is 30 seconds, while "1m" is still 1 minute.
+func MustDurationSeconds(variable string) time.Duration {
+	return mustDurationUnit(variable, time.Second)
+}
+
+// DurationSeconds is like Duration,
+// but interprets a bare integer value as a number of seconds:
+// "30" is 30 seconds, while "1m" is still 1 minute.
+func DurationSeconds(variable string, defaultValue time.Duration) time.Duration {
+	return durationUnit(variable, time.Second, defaultValue)
+}
+
+// MustDurationMillis is like MustDuration,
+// but interprets a bare integer value as a number of milliseconds:
+// "250" is 250 milliseconds, while "1s" is still 1 second.
+func MustDurationMillis(variable string) time.Duration {
+	return mustDurationUnit(variable, time.Millisecond)
+}
+
+// DurationMillis is like Duration,
+// but interprets a bare integer value as a number of milliseconds:
+// "250" is 250 milliseconds, while "1s" is still 1 second.
+func DurationMillis(variable string, defaultValue time.Duration) time.Duration {
+	return durationUnit(variable, time.Millisecond, defaultValue)
+}
+
+func mustDurationUnit(variable string, unit time.Duration) time.Duration {
+	value := Must(variable)
+	d, err := parseDurationUnit(value, unit)
+	if err != nil {
+		panic(fmt.Sprintf("environment variable %s must be a duration, %s given", variable, value))
+	}
+	return d
+}
+
+func durationUnit(variable string, unit, defaultValue time.Duration) time.Duration {
+	d, err := parseDurationUnit(Get(variable, ""), unit)
+	if err != nil {
+		recordDefault(variable)
+		return defaultValue
+	}
+	return d
+}
+
+func parseDurationUnit(value string, unit time.Duration) (time.Duration, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(n) * unit, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// MustURL returns *url.URL value of the environment variable.
+// It panics if variable is not present, or if value is not a valid URL.
+func MustURL(variable string) *url.URL {
+	value := Must(variable)
+	u, err := url.Parse(value)
+	if err != nil {
+		panic(fmt.Sprintf("environment variable %s must be a URL, %s given", variable, value))
+	}
+	return u
+}
+
+// URL returns *url.URL value of the environment variable.
+// If the variable is not present, is empty or is not a valid URL,
+// returns defaultValue.
+func URL(variable string, defaultValue *url.URL) *url.URL {
+	value := Get(variable, "")
+	if value == "" {
+		return defaultValue
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		recordDefault(variable)
+		return defaultValue
+	}
+	return u
+}
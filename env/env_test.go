@@ -1,8 +1,10 @@
 package env
 
 import (
+	"net/url"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestMust(t *testing.T) {
@@ -123,4 +125,232 @@ func TestBool(t *testing.T) {
 			t.Fatalf("Expected value to be %v but got %v", true, value)
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestMustInt(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "42")
+
+		value := MustInt("ENV_VAR")
+		if value != 42 {
+			t.Fatalf("Expected value to be %v but got %v", 42, value)
+		}
+	})
+
+	t.Run("panics on invalid integer", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		os.Setenv("ENV_VAR", "some")
+		_ = MustInt("ENV_VAR")
+	})
+}
+
+func TestInt(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "42")
+
+		value := Int("ENV_VAR", 1)
+		if value != 42 {
+			t.Fatalf("Expected value to be %v but got %v", 42, value)
+		}
+	})
+
+	t.Run("ok with default", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "some")
+
+		value := Int("ENV_VAR", 1)
+		if value != 1 {
+			t.Fatalf("Expected value to be %v but got %v", 1, value)
+		}
+	})
+}
+
+func TestMustFloat64(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "0.5")
+
+		value := MustFloat64("ENV_VAR")
+		if value != 0.5 {
+			t.Fatalf("Expected value to be %v but got %v", 0.5, value)
+		}
+	})
+
+	t.Run("panics on invalid float", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		os.Setenv("ENV_VAR", "some")
+		_ = MustFloat64("ENV_VAR")
+	})
+}
+
+func TestFloat64(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "0.5")
+
+		value := Float64("ENV_VAR", 1.5)
+		if value != 0.5 {
+			t.Fatalf("Expected value to be %v but got %v", 0.5, value)
+		}
+	})
+
+	t.Run("ok with default", func(t *testing.T) {
+		os.Clearenv()
+		value := Float64("ENV_VAR", 1.5)
+		if value != 1.5 {
+			t.Fatalf("Expected value to be %v but got %v", 1.5, value)
+		}
+	})
+}
+
+func TestMustDuration(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "1m30s")
+
+		value := MustDuration("ENV_VAR")
+		if value != time.Second*90 {
+			t.Fatalf("Expected value to be %v but got %v", time.Second*90, value)
+		}
+	})
+
+	t.Run("panics on invalid duration", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		os.Setenv("ENV_VAR", "30")
+		_ = MustDuration("ENV_VAR")
+	})
+}
+
+func TestDuration(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "5s")
+
+		value := Duration("ENV_VAR", time.Second)
+		if value != time.Second*5 {
+			t.Fatalf("Expected value to be %v but got %v", time.Second*5, value)
+		}
+	})
+
+	t.Run("ok with default", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "some")
+
+		value := Duration("ENV_VAR", time.Second)
+		if value != time.Second {
+			t.Fatalf("Expected value to be %v but got %v", time.Second, value)
+		}
+	})
+}
+
+func TestMustDurationSeconds(t *testing.T) {
+	t.Run("ok for bare integer", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "30")
+
+		value := MustDurationSeconds("ENV_VAR")
+		if value != time.Second*30 {
+			t.Fatalf("Expected value to be %v but got %v", time.Second*30, value)
+		}
+	})
+
+	t.Run("ok for duration with unit", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "1m")
+
+		value := MustDurationSeconds("ENV_VAR")
+		if value != time.Minute {
+			t.Fatalf("Expected value to be %v but got %v", time.Minute, value)
+		}
+	})
+
+	t.Run("panics on invalid duration", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		os.Setenv("ENV_VAR", "some")
+		_ = MustDurationSeconds("ENV_VAR")
+	})
+}
+
+func TestDurationMillis(t *testing.T) {
+	t.Run("ok for bare integer", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "250")
+
+		value := DurationMillis("ENV_VAR", time.Second)
+		if value != time.Millisecond*250 {
+			t.Fatalf("Expected value to be %v but got %v", time.Millisecond*250, value)
+		}
+	})
+
+	t.Run("ok with default", func(t *testing.T) {
+		os.Clearenv()
+
+		value := DurationMillis("ENV_VAR", time.Second)
+		if value != time.Second {
+			t.Fatalf("Expected value to be %v but got %v", time.Second, value)
+		}
+	})
+}
+
+func TestMustURL(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "https://example.com/path")
+
+		value := MustURL("ENV_VAR")
+		if value.Host != "example.com" {
+			t.Fatalf("Expected host to be %v but got %v", "example.com", value.Host)
+		}
+	})
+
+	t.Run("panics on invalid URL", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		os.Setenv("ENV_VAR", "http://[::1")
+		_ = MustURL("ENV_VAR")
+	})
+}
+
+func TestURL(t *testing.T) {
+	t.Run("ok with default", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("ENV_VAR", "http://[::1")
+
+		def, _ := url.Parse("http://localhost")
+		value := URL("ENV_VAR", def)
+		if value != def {
+			t.Fatalf("Expected value to be %v but got %v", def, value)
+		}
+	})
+}
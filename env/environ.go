@@ -0,0 +1,66 @@
+package env
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Environ returns the variables of the environment that are in the
+// allowlist, in the "NAME=value" form of os.Environ, sorted by name.
+// A name ending with "*" allows all variables with that prefix.
+// It is meant for exec.Cmd.Env, so child processes get only the variables
+// they need instead of the whole environment of the parent:
+//
+//	cmd.Env = env.Environ("PATH", "HOME", "AWS_*")
+func Environ(allowlist ...string) []string {
+	var environ []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if allowed(name, allowlist) {
+			environ = append(environ, kv)
+		}
+	}
+	sort.Strings(environ)
+	return environ
+}
+
+func allowed(name string, allowlist []string) bool {
+	for _, a := range allowlist {
+		if prefix, ok := strings.CutSuffix(a, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Environ returns the variables of the scope, without prefixes, in the
+// "NAME=value" form of os.Environ, sorted by name. If a variable is present
+// with several prefixes, the one with the highest precedence wins, as with
+// Lookup. Variables without any of the prefixes are not included; combine
+// with the package-level Environ to pass them.
+//
+//	s := env.Scoped("BILLING_", "GLOBAL_")
+//	cmd.Env = append(env.Environ("PATH"), s.Environ()...)
+func (s Scope) Environ() []string {
+	values := make(map[string]string)
+	for i := len(s.prefixes) - 1; i >= 0; i-- {
+		for _, kv := range os.Environ() {
+			name, value, _ := strings.Cut(kv, "=")
+			if variable, ok := strings.CutPrefix(name, s.prefixes[i]); ok && variable != "" {
+				values[variable] = value
+			}
+		}
+	}
+
+	environ := make([]string, 0, len(values))
+	for variable, value := range values {
+		environ = append(environ, variable+"="+value)
+	}
+	sort.Strings(environ)
+	return environ
+}
@@ -0,0 +1,38 @@
+package env
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestEnviron(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PATH", "/bin")
+	os.Setenv("HOME", "/root")
+	os.Setenv("AWS_REGION", "eu-west-1")
+	os.Setenv("AWS_PROFILE", "dev")
+	os.Setenv("DB_PASSWORD", "secret")
+
+	expected := []string{"AWS_PROFILE=dev", "AWS_REGION=eu-west-1", "PATH=/bin"}
+	if v := Environ("PATH", "AWS_*", "MISSING"); !reflect.DeepEqual(v, expected) {
+		t.Fatalf("Expected %v but got %v", expected, v)
+	}
+
+	if v := Environ(); len(v) != 0 {
+		t.Fatalf("Expected empty environment but got %v", v)
+	}
+}
+
+func TestScope_Environ(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("BILLING_LOG_LEVEL", "debug")
+	os.Setenv("GLOBAL_LOG_LEVEL", "info")
+	os.Setenv("GLOBAL_REGION", "eu")
+	os.Setenv("PORT", "8080")
+
+	expected := []string{"LOG_LEVEL=debug", "REGION=eu"}
+	if v := Scoped("BILLING_", "GLOBAL_").Environ(); !reflect.DeepEqual(v, expected) {
+		t.Fatalf("Expected %v but got %v", expected, v)
+	}
+}
@@ -0,0 +1,43 @@
+package env
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ExpandAll replaces $VAR and ${VAR} references in text with the values
+// of the environment variables, like os.Expand does. In addition,
+// ${VAR:-default} is replaced with default if the variable is not present
+// or is empty, following the semantics of Get.
+//
+// It is useful to template whole config files:
+//
+//	dsn: postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST:-localhost}/app
+func ExpandAll(text string) string {
+	return os.Expand(text, expandVariable)
+}
+
+// ExpandReader reads everything from r and returns a reader
+// of the content expanded with ExpandAll. It can be used
+// to expand config files before decoding them:
+//
+//	r, err := env.ExpandReader(f)
+//	if err != nil { ... }
+//	err = json.NewDecoder(r).Decode(&cfg)
+func ExpandReader(r io.Reader) (io.Reader, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader([]byte(ExpandAll(string(b)))), nil
+}
+
+func expandVariable(name string) string {
+	if i := strings.Index(name, ":-"); i >= 0 {
+		return Get(name[:i], name[i+2:])
+	}
+	return Get(name, "")
+}
@@ -0,0 +1,35 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExpandAll(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_USER", "app")
+	os.Setenv("DB_HOST", "")
+
+	value := ExpandAll("postgres://${DB_USER}@${DB_HOST:-localhost}:${DB_PORT:-5432}/$DB_USER")
+	expected := "postgres://app@localhost:5432/app"
+	if value != expected {
+		t.Fatalf("Expected value to be %q but got %q", expected, value)
+	}
+}
+
+func TestExpandReader(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NAME", "world")
+
+	r, err := ExpandReader(strings.NewReader("hello: ${NAME}\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	b, _ := ioutil.ReadAll(r)
+	if string(b) != "hello: world\n" {
+		t.Fatalf("Expected value to be %q but got %q", "hello: world\n", string(b))
+	}
+}
@@ -0,0 +1,58 @@
+package env
+
+import (
+	"sort"
+	"strings"
+)
+
+// Flag returns the state of the feature flag stored in the environment
+// variable. Unlike Bool, it accepts the common spellings of the states,
+// case-insensitive: "true", "1", "yes", "on" and "false", "0", "no", "off".
+// If the variable is not present, is empty or has another value,
+// returns defaultValue.
+func Flag(variable string, defaultValue bool) bool {
+	switch strings.ToLower(strings.TrimSpace(Get(variable, ""))) {
+	case "true", "1", "yes", "on":
+		return true
+	case "false", "0", "no", "off":
+		return false
+	default:
+		recordDefault(variable)
+		return defaultValue
+	}
+}
+
+// FlagSet is a set of enabled feature flags.
+type FlagSet map[string]struct{}
+
+// Has reports whether the flag is enabled.
+func (fs FlagSet) Has(flag string) bool {
+	_, ok := fs[flag]
+	return ok
+}
+
+// List returns the enabled flags in sorted order.
+func (fs FlagSet) List() []string {
+	list := make([]string, 0, len(fs))
+	for flag := range fs {
+		list = append(list, flag)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Flags returns the set of feature flags enabled by the environment
+// variable, which contains a comma-separated list of flag names:
+//
+//	FEATURES=new-checkout, dark-mode
+//
+// If the variable is not present or is empty, returns an empty set.
+func Flags(variable string) FlagSet {
+	fs := make(FlagSet)
+	for _, flag := range strings.Split(Get(variable, ""), ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			fs[flag] = struct{}{}
+		}
+	}
+	return fs
+}
@@ -0,0 +1,62 @@
+package env
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFlag(t *testing.T) {
+	for _, value := range []string{"true", "1", "YES", "on"} {
+		os.Clearenv()
+		os.Setenv("FEATURE_X", value)
+
+		if !Flag("FEATURE_X", false) {
+			t.Fatalf("Expected flag to be enabled for %q", value)
+		}
+	}
+
+	for _, value := range []string{"false", "0", "no", "OFF"} {
+		os.Clearenv()
+		os.Setenv("FEATURE_X", value)
+
+		if Flag("FEATURE_X", true) {
+			t.Fatalf("Expected flag to be disabled for %q", value)
+		}
+	}
+
+	t.Run("ok with default", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("FEATURE_X", "some")
+
+		if !Flag("FEATURE_X", true) {
+			t.Fatalf("Expected value to be %v", true)
+		}
+	})
+}
+
+func TestFlags(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("FEATURES", "new-checkout, dark-mode,,")
+
+		fs := Flags("FEATURES")
+		if !fs.Has("new-checkout") || !fs.Has("dark-mode") {
+			t.Fatalf("Expected flags to be enabled: %v", fs.List())
+		}
+		if fs.Has("other") {
+			t.Fatalf("Expected flag not to be enabled")
+		}
+		if !reflect.DeepEqual(fs.List(), []string{"dark-mode", "new-checkout"}) {
+			t.Fatalf("Unexpected flags: %v", fs.List())
+		}
+	})
+
+	t.Run("ok for empty", func(t *testing.T) {
+		os.Clearenv()
+
+		if fs := Flags("FEATURES"); len(fs) != 0 {
+			t.Fatalf("Expected no flags but got %v", fs.List())
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MustIndexed returns values of the indexed environment variables
+// VARIABLE_0, VARIABLE_1, and so on.
+//
+// If VARIABLE_COUNT is present, exactly that many values are returned.
+// It panics if VARIABLE_COUNT is not a valid non-negative integer
+// or any of the counted variables is not present.
+//
+// Otherwise, values are collected until the first missing index.
+// It panics if VARIABLE_0 is not present.
+func MustIndexed(variable string) []string {
+	variable = strings.TrimPrefix(variable, "$")
+
+	count, ok := os.LookupEnv(variable + "_COUNT")
+	if !ok {
+		values := Indexed(variable)
+		if len(values) == 0 {
+			panic(fmt.Sprintf("variable %s_0 is not present in the environment", variable))
+		}
+		return values
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		panic(fmt.Sprintf("environment variable %s_COUNT must be a non-negative integer, %s given", variable, count))
+	}
+
+	values := make([]string, n)
+	for i := range values {
+		values[i] = Must(variable + "_" + strconv.Itoa(i))
+	}
+	return values
+}
+
+// Indexed returns values of the indexed environment variables
+// VARIABLE_0, VARIABLE_1, and so on. This is a common way to pass a list
+// where a single variable cannot express it, e.g. in orchestration templates.
+//
+// If VARIABLE_COUNT is present and is a valid non-negative integer,
+// exactly that many values are returned, with empty strings for missing
+// variables. Otherwise, values are collected until the first missing index.
+func Indexed(variable string) []string {
+	variable = strings.TrimPrefix(variable, "$")
+
+	if n, err := strconv.Atoi(os.Getenv(variable + "_COUNT")); err == nil && n >= 0 {
+		values := make([]string, n)
+		for i := range values {
+			values[i] = os.Getenv(variable + "_" + strconv.Itoa(i))
+		}
+		return values
+	}
+
+	var values []string
+	for i := 0; ; i++ {
+		value, ok := os.LookupEnv(variable + "_" + strconv.Itoa(i))
+		if !ok {
+			return values
+		}
+		values = append(values, value)
+	}
+}
@@ -0,0 +1,79 @@
+package env
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestIndexed(t *testing.T) {
+	t.Run("ok until first missing index", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("PEER_0", "a")
+		os.Setenv("PEER_1", "b")
+		os.Setenv("PEER_3", "d")
+
+		value := Indexed("PEER")
+		if !reflect.DeepEqual(value, []string{"a", "b"}) {
+			t.Fatalf("Expected value to be %v but got %v", []string{"a", "b"}, value)
+		}
+	})
+
+	t.Run("ok with count", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("PEER_COUNT", "3")
+		os.Setenv("PEER_0", "a")
+		os.Setenv("PEER_2", "c")
+
+		value := Indexed("PEER")
+		if !reflect.DeepEqual(value, []string{"a", "", "c"}) {
+			t.Fatalf("Expected value to be %v but got %v", []string{"a", "", "c"}, value)
+		}
+	})
+
+	t.Run("ok for none", func(t *testing.T) {
+		os.Clearenv()
+
+		if value := Indexed("PEER"); len(value) != 0 {
+			t.Fatalf("Expected no values but got %v", value)
+		}
+	})
+}
+
+func TestMustIndexed(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("PEER_COUNT", "2")
+		os.Setenv("PEER_0", "a")
+		os.Setenv("PEER_1", "b")
+
+		value := MustIndexed("PEER")
+		if !reflect.DeepEqual(value, []string{"a", "b"}) {
+			t.Fatalf("Expected value to be %v but got %v", []string{"a", "b"}, value)
+		}
+	})
+
+	t.Run("panics on missing counted variable", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		os.Setenv("PEER_COUNT", "2")
+		os.Setenv("PEER_0", "a")
+		_ = MustIndexed("PEER")
+	})
+
+	t.Run("panics on no values", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		_ = MustIndexed("PEER")
+	})
+}
@@ -0,0 +1,257 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissing is reported for a required variable that is not present
+// in the environment.
+var ErrMissing = errors.New("not present in the environment")
+
+// VariableError describes a problem with a single environment variable.
+type VariableError struct {
+	Variable string
+	Err      error
+}
+
+func (e *VariableError) Error() string {
+	return fmt.Sprintf("variable %s: %s", e.Variable, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *VariableError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of problems with environment variables.
+type Errors []*VariableError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Load populates the struct pointed to by v from the environment.
+//
+// Only fields with an `env` tag are populated. The tag has the form:
+//
+//	`env:"NAME,required,default=value"`
+//
+// The "required" option reports an error if the variable is not present.
+// The "default" option is used when the variable is not present or is empty;
+// it must be the last option, so the value may contain commas.
+// The "secret" option does not affect loading; it marks the variable
+// for Declarations.
+//
+// Defaults may depend on the Profile, with options that go before "default"
+// and cannot contain commas:
+//
+//	`env:"LOG_LEVEL,default.dev=debug,default.prod=warn,default=info"`
+//
+// Nested struct fields are loaded recursively. If a nested struct field is
+// tagged, its tag name is used as the prefix for the variables of its fields:
+//
+//	type Config struct {
+//	    DB struct {
+//	        Host string `env:"HOST,required"` // loaded from DB_HOST
+//	    } `env:"DB_"`
+//	}
+//
+// Supported field types are string, bool, all integer and float types,
+// time.Duration, types implementing encoding.TextUnmarshaler or flag.Value,
+// types registered with RegisterParser, and slices of them.
+// Slice values are comma-separated.
+//
+// Load does not stop on the first problem; it returns Errors listing
+// all missing and invalid variables.
+func Load(v interface{}) error {
+	return load(v, lookupEnv)
+}
+
+// MustLoad is like Load but panics if any variable is missing or invalid.
+func MustLoad(v interface{}) {
+	if err := Load(v); err != nil {
+		panic(err.Error())
+	}
+}
+
+// lookupEnv is os.LookupEnv that records provenance.
+func lookupEnv(variable string) (string, bool) {
+	value, ok := os.LookupEnv(variable)
+	if ok {
+		recordEnv(variable)
+	}
+	return value, ok
+}
+
+func load(v interface{}, lookup func(string) (string, bool)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("env: Load expects a non-nil pointer to a struct")
+	}
+
+	var errs Errors
+	loadStruct(rv.Elem(), "", lookup, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type fieldTag struct {
+	name            string
+	required        bool
+	secret          bool
+	defaultValue    string
+	hasDefault      bool
+	profileDefaults map[Environment]string
+}
+
+// lookupDefault returns the default for the current profile.
+func (ft fieldTag) lookupDefault() (string, bool) {
+	if value, ok := ft.profileDefaults[Profile()]; ok {
+		return value, true
+	}
+	return ft.defaultValue, ft.hasDefault
+}
+
+func parseTag(tag string) fieldTag {
+	var ft fieldTag
+	parts := strings.Split(tag, ",")
+	ft.name = strings.TrimPrefix(parts[0], "$")
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case parts[i] == "required":
+			ft.required = true
+		case parts[i] == "secret":
+			ft.secret = true
+		case strings.HasPrefix(parts[i], "default."):
+			kv := strings.SplitN(strings.TrimPrefix(parts[i], "default."), "=", 2)
+			if len(kv) == 2 {
+				if ft.profileDefaults == nil {
+					ft.profileDefaults = make(map[Environment]string)
+				}
+				ft.profileDefaults[Environment(kv[0])] = kv[1]
+			}
+		case strings.HasPrefix(parts[i], "default="):
+			ft.defaultValue = strings.TrimPrefix(strings.Join(parts[i:], ","), "default=")
+			ft.hasDefault = true
+			return ft
+		}
+	}
+	return ft
+}
+
+func loadStruct(rv reflect.Value, prefix string, lookup func(string) (string, bool), errs *Errors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+
+		tag, tagged := field.Tag.Lookup("env")
+		if isNested(field.Type) {
+			loadStruct(fv, prefix+parseTag(tag).name, lookup, errs)
+			continue
+		}
+		if !tagged {
+			continue
+		}
+
+		ft := parseTag(tag)
+		variable := prefix + ft.name
+
+		value, ok := lookup(variable)
+		if !ok || value == "" {
+			if def, hasDefault := ft.lookupDefault(); hasDefault {
+				value, ok = def, true
+				recordDefault(variable)
+			}
+		}
+		if !ok {
+			if ft.required {
+				*errs = append(*errs, &VariableError{Variable: variable, Err: ErrMissing})
+			}
+			continue
+		}
+
+		if err := setValue(fv, value); err != nil {
+			*errs = append(*errs, &VariableError{Variable: variable, Err: err})
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(fv reflect.Value, value string) error {
+	if ok, err := setCustom(fv, value); ok {
+		return err
+	}
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration, %s given", value)
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		switch value {
+		case "true":
+			fv.SetBool(true)
+		case "false":
+			fv.SetBool(false)
+		default:
+			return fmt.Errorf("must be either true or false, %s given", value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer, %s given", value)
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an unsigned integer, %s given", value)
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a float, %s given", value)
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
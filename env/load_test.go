@@ -0,0 +1,112 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Addr    string        `env:"HTTP_ADDR,default=:8080"`
+	Debug   bool          `env:"DEBUG"`
+	Workers int           `env:"WORKERS,required"`
+	Ratio   float64       `env:"RATIO,default=0.5"`
+	Timeout time.Duration `env:"TIMEOUT,default=5s"`
+	Hosts   []string      `env:"HOSTS,default=a,b"`
+	Ports   []uint16      `env:"PORTS"`
+
+	DB struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT,default=5432"`
+	} `env:"DB_"`
+
+	ignored string
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DEBUG", "true")
+		os.Setenv("WORKERS", "4")
+		os.Setenv("PORTS", "80, 443")
+		os.Setenv("DB_HOST", "localhost")
+
+		var cfg testConfig
+		if err := Load(&cfg); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if cfg.Addr != ":8080" {
+			t.Fatalf("Expected Addr to be %q but got %q", ":8080", cfg.Addr)
+		}
+		if !cfg.Debug {
+			t.Fatalf("Expected Debug to be %v but got %v", true, cfg.Debug)
+		}
+		if cfg.Workers != 4 {
+			t.Fatalf("Expected Workers to be %v but got %v", 4, cfg.Workers)
+		}
+		if cfg.Ratio != 0.5 {
+			t.Fatalf("Expected Ratio to be %v but got %v", 0.5, cfg.Ratio)
+		}
+		if cfg.Timeout != time.Second*5 {
+			t.Fatalf("Expected Timeout to be %v but got %v", time.Second*5, cfg.Timeout)
+		}
+		if !reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) {
+			t.Fatalf("Expected Hosts to be %v but got %v", []string{"a", "b"}, cfg.Hosts)
+		}
+		if !reflect.DeepEqual(cfg.Ports, []uint16{80, 443}) {
+			t.Fatalf("Expected Ports to be %v but got %v", []uint16{80, 443}, cfg.Ports)
+		}
+		if cfg.DB.Host != "localhost" || cfg.DB.Port != 5432 {
+			t.Fatalf("Unexpected DB config: %+v", cfg.DB)
+		}
+	})
+
+	t.Run("collects all problems", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DEBUG", "yes")
+		os.Setenv("TIMEOUT", "30")
+
+		var cfg testConfig
+		err := Load(&cfg)
+
+		var errs Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("Expected Errors but got %v", err)
+		}
+
+		var variables []string
+		for _, e := range errs {
+			variables = append(variables, e.Variable)
+		}
+		expected := []string{"DEBUG", "WORKERS", "TIMEOUT", "DB_HOST"}
+		if !reflect.DeepEqual(variables, expected) {
+			t.Fatalf("Expected problems with %v but got %v", expected, variables)
+		}
+		if !errors.Is(errs[1], ErrMissing) {
+			t.Fatalf("Expected WORKERS to be reported as missing but got %v", errs[1])
+		}
+	})
+
+	t.Run("fails on non-pointer", func(t *testing.T) {
+		if err := Load(testConfig{}); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+func TestMustLoad(t *testing.T) {
+	t.Run("panics on missing variables", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		var cfg testConfig
+		MustLoad(&cfg)
+	})
+}
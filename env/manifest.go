@@ -0,0 +1,179 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Declaration describes a variable declared by a struct for Load.
+type Declaration struct {
+	Name     string
+	Type     string
+	Required bool
+	Secret   bool
+
+	// Default is the default value, empty if there is none.
+	Default string
+
+	// ProfileDefaults are the defaults for profiles, see Load.
+	ProfileDefaults map[Environment]string
+
+	// Description is the text of the `desc` struct tag of the field.
+	Description string
+}
+
+// DefaultFor returns the default value of the variable for the profile.
+func (d Declaration) DefaultFor(profile Environment) string {
+	if value, ok := d.ProfileDefaults[profile]; ok {
+		return value
+	}
+	return d.Default
+}
+
+// Declarations returns the variables declared by the struct pointed to
+// by v, in field order, resolving nested prefixes as Load does.
+// They describe the environment contract of the app, which can be emitted
+// with WriteConfigMap, WriteContainerEnv and WriteMarkdown.
+//
+// The "secret" option of the env tag, which must go before the default
+// option, marks variables to be supplied from a Secret:
+//
+//	Password string `env:"DB_PASSWORD,required,secret" desc:"Database password."`
+func Declarations(v interface{}) ([]Declaration, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("env: Declarations expects a non-nil pointer to a struct")
+	}
+	return declare(rv.Elem().Type(), "", nil), nil
+}
+
+func declare(rt reflect.Type, prefix string, decls []Declaration) []Declaration {
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag, tagged := field.Tag.Lookup("env")
+		if isNested(field.Type) {
+			decls = declare(field.Type, prefix+parseTag(tag).name, decls)
+			continue
+		}
+		if !tagged {
+			continue
+		}
+
+		ft := parseTag(tag)
+		decls = append(decls, Declaration{
+			Name:            prefix + ft.name,
+			Type:            field.Type.String(),
+			Required:        ft.required,
+			Secret:          ft.secret,
+			Default:         ft.defaultValue,
+			ProfileDefaults: ft.profileDefaults,
+			Description:     field.Tag.Get("desc"),
+		})
+	}
+	return decls
+}
+
+// WriteConfigMap writes a Kubernetes ConfigMap manifest in YAML with the
+// variables that are not secret. A ConfigMap value overrides the defaults
+// of Load, so only variables with a plain default are set to it. Required
+// variables and variables with profile defaults are written as comments
+// for the operator to fill in or leave out.
+func WriteConfigMap(w io.Writer, name string, decls []Declaration) error {
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\nkind: ConfigMap\nmetadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", yamlString(name))
+	b.WriteString("data:\n")
+	for _, d := range decls {
+		if d.Secret {
+			continue
+		}
+		if d.Required || d.Default == "" || len(d.ProfileDefaults) > 0 {
+			b.WriteString("  # ")
+		} else {
+			b.WriteString("  ")
+		}
+		fmt.Fprintf(&b, "%s: %s\n", d.Name, yamlString(d.Default))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteContainerEnv writes the env section of a Kubernetes container spec
+// in YAML, indented to be pasted into a Deployment. Variables are taken
+// from the ConfigMap named configMap, or from the Secret named secret
+// if they are secret. Variables that are not required are optional.
+func WriteContainerEnv(w io.Writer, configMap, secret string, decls []Declaration) error {
+	var b strings.Builder
+	b.WriteString("env:\n")
+	for _, d := range decls {
+		ref, source := "configMapKeyRef", configMap
+		if d.Secret {
+			ref, source = "secretKeyRef", secret
+		}
+		fmt.Fprintf(&b, "  - name: %s\n", d.Name)
+		b.WriteString("    valueFrom:\n")
+		fmt.Fprintf(&b, "      %s:\n", ref)
+		fmt.Fprintf(&b, "        name: %s\n", yamlString(source))
+		fmt.Fprintf(&b, "        key: %s\n", d.Name)
+		if !d.Required {
+			b.WriteString("        optional: true\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the documentation of the variables
+// as a Markdown table.
+func WriteMarkdown(w io.Writer, decls []Declaration) error {
+	var b strings.Builder
+	b.WriteString("| Variable | Type | Required | Default | Description |\n")
+	b.WriteString("|----------|------|----------|---------|-------------|\n")
+	for _, d := range decls {
+		required := "no"
+		if d.Required {
+			required = "yes"
+		}
+		fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s |\n",
+			d.Name, d.Type, required, markdownDefault(d), markdownEscape(d.Description))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownDefault(d Declaration) string {
+	var parts []string
+	if d.Default != "" {
+		parts = append(parts, "`"+markdownEscape(d.Default)+"`")
+	}
+
+	profiles := make([]string, 0, len(d.ProfileDefaults))
+	for p := range d.ProfileDefaults {
+		profiles = append(profiles, string(p))
+	}
+	sort.Strings(profiles)
+	for _, p := range profiles {
+		parts = append(parts, p+": `"+markdownEscape(d.ProfileDefaults[Environment(p)])+"`")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// yamlString returns s quoted as a YAML string.
+// JSON strings are valid YAML.
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
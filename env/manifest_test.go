@@ -0,0 +1,93 @@
+package env
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type manifestConfig struct {
+	LogLevel string `env:"LOG_LEVEL,default.prod=warn,default=info" desc:"One of debug|info|warn."`
+	DB       struct {
+		Host     string `env:"HOST,required" desc:"Database host."`
+		Password string `env:"PASSWORD,required,secret"`
+	} `env:"DB_"`
+	Timeout time.Duration `env:"TIMEOUT,default=5s"`
+	Since   time.Time     `env:"SINCE"`
+	ignored string
+}
+
+func TestDeclarations(t *testing.T) {
+	decls, err := Declarations(&manifestConfig{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Run("ConfigMap", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteConfigMap(&buf, "app", decls); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: "app"
+data:
+  # LOG_LEVEL: "info"
+  # DB_HOST: ""
+  TIMEOUT: "5s"
+  # SINCE: ""
+`
+		if buf.String() != expected {
+			t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, buf.String())
+		}
+	})
+
+	t.Run("container env", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteContainerEnv(&buf, "app", "app-secrets", decls[1:3]); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := `env:
+  - name: DB_HOST
+    valueFrom:
+      configMapKeyRef:
+        name: "app"
+        key: DB_HOST
+  - name: DB_PASSWORD
+    valueFrom:
+      secretKeyRef:
+        name: "app-secrets"
+        key: DB_PASSWORD
+`
+		if buf.String() != expected {
+			t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, buf.String())
+		}
+	})
+
+	t.Run("Markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteMarkdown(&buf, decls); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := "| Variable | Type | Required | Default | Description |\n" +
+			"|----------|------|----------|---------|-------------|\n" +
+			"| `LOG_LEVEL` | `string` | no | `info`, prod: `warn` | One of debug\\|info\\|warn. |\n" +
+			"| `DB_HOST` | `string` | yes |  | Database host. |\n" +
+			"| `DB_PASSWORD` | `string` | yes |  |  |\n" +
+			"| `TIMEOUT` | `time.Duration` | no | `5s` |  |\n" +
+			"| `SINCE` | `time.Time` | no |  |  |\n"
+		if buf.String() != expected {
+			t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, buf.String())
+		}
+	})
+
+	t.Run("invalid argument", func(t *testing.T) {
+		if _, err := Declarations(manifestConfig{}); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
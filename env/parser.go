@@ -0,0 +1,120 @@
+package env
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var parsers = struct {
+	mx sync.RWMutex
+	m  map[reflect.Type]func(string) (reflect.Value, error)
+}{
+	m: make(map[reflect.Type]func(string) (reflect.Value, error)),
+}
+
+// RegisterParser registers the parser for values of type T, so Load,
+// Parse and MustParse support it:
+//
+//	env.RegisterParser(func(s string) (Region, error) { ... })
+//
+// Types implementing encoding.TextUnmarshaler or flag.Value with a pointer
+// receiver are supported without registration; a registered parser takes
+// precedence over those methods.
+func RegisterParser[T any](parse func(string) (T, error)) {
+	parsers.mx.Lock()
+	defer parsers.mx.Unlock()
+
+	parsers.m[reflect.TypeOf((*T)(nil)).Elem()] = func(value string) (reflect.Value, error) {
+		v, err := parse(value)
+		return reflect.ValueOf(&v).Elem(), err
+	}
+}
+
+// MustParse returns the value of the environment variable parsed as T.
+// T may be any type supported by Load.
+// It panics if the variable is not present, or if the value is invalid.
+func MustParse[T any](variable string) T {
+	value := Must(variable)
+	var v T
+	if err := setValue(reflect.ValueOf(&v).Elem(), value); err != nil {
+		panic(fmt.Sprintf("environment variable %s %s", strings.TrimPrefix(variable, "$"), err))
+	}
+	return v
+}
+
+// Parse returns the value of the environment variable parsed as T.
+// T may be any type supported by Load.
+// If the variable is not present, is empty or is invalid,
+// returns defaultValue.
+func Parse[T any](variable string, defaultValue T) T {
+	value := os.Getenv(strings.TrimPrefix(variable, "$"))
+	var v T
+	if value == "" || setValue(reflect.ValueOf(&v).Elem(), value) != nil {
+		recordDefault(variable)
+		return defaultValue
+	}
+	recordEnv(variable)
+	return v
+}
+
+// setCustom sets the value with a registered parser, UnmarshalText or Set.
+// It reports false if the type supports none of them.
+func setCustom(fv reflect.Value, value string) (bool, error) {
+	parsers.mx.RLock()
+	parse, ok := parsers.m[fv.Type()]
+	parsers.mx.RUnlock()
+	if ok {
+		v, err := parse(value)
+		if err != nil {
+			return true, fmt.Errorf("must be a valid %s, %s given: %w", fv.Type(), value, err)
+		}
+		fv.Set(v)
+		return true, nil
+	}
+
+	if !fv.CanAddr() {
+		return false, nil
+	}
+	switch u := fv.Addr().Interface().(type) {
+	case encoding.TextUnmarshaler:
+		if err := u.UnmarshalText([]byte(value)); err != nil {
+			return true, fmt.Errorf("must be a valid %s, %s given: %w", fv.Type(), value, err)
+		}
+		return true, nil
+	case flag.Value:
+		if err := u.Set(value); err != nil {
+			return true, fmt.Errorf("must be a valid %s, %s given: %w", fv.Type(), value, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+// isNested reports whether a field of type t is a nested struct whose
+// fields are loaded recursively, rather than a single value like
+// time.Time or a type with a registered parser.
+func isNested(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	parsers.mx.RLock()
+	_, ok := parsers.m[t]
+	parsers.mx.RUnlock()
+	if ok {
+		return false
+	}
+
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(textUnmarshalerType) && !pt.Implements(flagValueType)
+}
@@ -0,0 +1,139 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type region string
+
+type point struct{ X, Y int }
+
+type logFormat string
+
+func (f *logFormat) String() string { return string(*f) }
+
+func (f *logFormat) Set(value string) error {
+	switch value {
+	case "json", "text":
+		*f = logFormat(value)
+		return nil
+	}
+	return errors.New("unknown format")
+}
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser(func(s string) (region, error) {
+		if !strings.Contains(s, "-") {
+			return "", errors.New("region must look like eu-west-1")
+		}
+		return region(s), nil
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("REGION", "eu-west-1")
+		os.Setenv("REGIONS", "eu-west-1,us-east-1")
+		os.Setenv("LOG_FORMAT", "json")
+		os.Setenv("LISTEN_IP", "10.0.0.1")
+
+		var cfg struct {
+			Region    region    `env:"REGION"`
+			Regions   []region  `env:"REGIONS"`
+			LogFormat logFormat `env:"LOG_FORMAT"`
+			ListenIP  net.IP    `env:"LISTEN_IP"`
+		}
+		if err := Load(&cfg); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if cfg.Region != "eu-west-1" || !reflect.DeepEqual(cfg.Regions, []region{"eu-west-1", "us-east-1"}) {
+			t.Fatalf("Unexpected regions: %v %v", cfg.Region, cfg.Regions)
+		}
+		if cfg.LogFormat != "json" {
+			t.Fatalf("Expected log format to be %q but got %q", "json", cfg.LogFormat)
+		}
+		if !cfg.ListenIP.Equal(net.ParseIP("10.0.0.1")) {
+			t.Fatalf("Unexpected IP: %v", cfg.ListenIP)
+		}
+	})
+
+	t.Run("loads struct values", func(t *testing.T) {
+		RegisterParser(func(s string) (point, error) {
+			var p point
+			_, err := fmt.Sscanf(s, "%d:%d", &p.X, &p.Y)
+			return p, err
+		})
+
+		os.Clearenv()
+		os.Setenv("SINCE", "2024-01-02T03:04:05Z")
+		os.Setenv("ORIGIN", "1:2")
+
+		var cfg struct {
+			Since  time.Time `env:"SINCE,required"`
+			Origin point     `env:"ORIGIN,required"`
+		}
+		if err := Load(&cfg); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !cfg.Since.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Fatalf("Unexpected time: %v", cfg.Since)
+		}
+		if cfg.Origin != (point{1, 2}) {
+			t.Fatalf("Unexpected point: %v", cfg.Origin)
+		}
+
+		os.Clearenv()
+		err := Load(&cfg)
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 2 {
+			t.Fatalf("Expected both required variables to be reported but got %v", err)
+		}
+	})
+
+	t.Run("reports invalid values", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("REGION", "mars")
+
+		var cfg struct {
+			Region region `env:"REGION"`
+		}
+		if err := Load(&cfg); err == nil || !strings.Contains(err.Error(), "eu-west-1") {
+			t.Fatalf("Expected parser error but got %v", err)
+		}
+	})
+
+	t.Run("Parse and MustParse", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("REGION", "eu-west-1")
+		os.Setenv("LOG_FORMAT", "yaml")
+		os.Setenv("TIMEOUT", "3s")
+
+		if v := MustParse[region]("REGION"); v != "eu-west-1" {
+			t.Fatalf("Unexpected region: %v", v)
+		}
+		if v := Parse[logFormat]("LOG_FORMAT", "text"); v != "text" {
+			t.Fatalf("Expected default for invalid value but got %v", v)
+		}
+		if v := Parse("TIMEOUT", time.Second); v != time.Second*3 {
+			t.Fatalf("Expected builtin type support but got %v", v)
+		}
+	})
+
+	t.Run("MustParse panics on invalid value", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("REGION", "mars")
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected panic")
+			}
+		}()
+
+		MustParse[region]("REGION")
+	})
+}
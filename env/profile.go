@@ -0,0 +1,62 @@
+package env
+
+import (
+	"os"
+	"strings"
+)
+
+// Environment is a deployment environment of the app.
+type Environment string
+
+// Environments recognized by Profile.
+const (
+	Dev     Environment = "dev"
+	Test    Environment = "test"
+	Staging Environment = "staging"
+	Prod    Environment = "prod"
+)
+
+// Profile returns the environment the app runs in, from the APP_ENV
+// variable or, if it is not set, from GO_ENV. The common spellings like
+// "development" and "production" are recognized, case-insensitive;
+// other values are returned lowercased as is. If neither variable
+// is set, Profile returns Dev.
+func Profile() Environment {
+	value := os.Getenv("APP_ENV")
+	if value == "" {
+		value = os.Getenv("GO_ENV")
+	}
+
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "", "dev", "development", "local":
+		return Dev
+	case "test", "testing":
+		return Test
+	case "staging", "stage":
+		return Staging
+	case "prod", "production":
+		return Prod
+	default:
+		return Environment(value)
+	}
+}
+
+// IsDev reports whether the app runs in the Dev environment.
+func IsDev() bool {
+	return Profile() == Dev
+}
+
+// IsTest reports whether the app runs in the Test environment.
+func IsTest() bool {
+	return Profile() == Test
+}
+
+// IsStaging reports whether the app runs in the Staging environment.
+func IsStaging() bool {
+	return Profile() == Staging
+}
+
+// IsProd reports whether the app runs in the Prod environment.
+func IsProd() bool {
+	return Profile() == Prod
+}
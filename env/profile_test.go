@@ -0,0 +1,73 @@
+package env
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	tests := []struct {
+		appEnv   string
+		goEnv    string
+		expected Environment
+	}{
+		{"", "", Dev},
+		{"production", "", Prod},
+		{"", "Testing", Test},
+		{"stage", "prod", Staging},
+		{"qa", "", Environment("qa")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.appEnv+"/"+tc.goEnv, func(t *testing.T) {
+			os.Clearenv()
+			if tc.appEnv != "" {
+				os.Setenv("APP_ENV", tc.appEnv)
+			}
+			if tc.goEnv != "" {
+				os.Setenv("GO_ENV", tc.goEnv)
+			}
+
+			if p := Profile(); p != tc.expected {
+				t.Fatalf("Expected profile to be %q but got %q", tc.expected, p)
+			}
+		})
+	}
+
+	t.Run("IsProd", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("APP_ENV", "prod")
+		if !IsProd() || IsDev() {
+			t.Fatalf("Expected prod profile")
+		}
+	})
+}
+
+func TestLoad_ProfileDefaults(t *testing.T) {
+	var cfg struct {
+		Level string `env:"LOG_LEVEL,default.dev=debug,default.prod=warn,default=info"`
+		Hosts string `env:"HOSTS,default.prod=a,default=b,c"`
+	}
+
+	tests := []struct {
+		profile string
+		level   string
+		hosts   string
+	}{
+		{"", "debug", "b,c"},
+		{"prod", "warn", "a"},
+		{"staging", "info", "b,c"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.profile, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("APP_ENV", tc.profile)
+
+			if err := Load(&cfg); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if cfg.Level != tc.level || cfg.Hosts != tc.hosts {
+				t.Fatalf("Expected %q and %q but got %q and %q", tc.level, tc.hosts, cfg.Level, cfg.Hosts)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package env
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// Source is where a value came from.
+type Source string
+
+// Sources of values.
+const (
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+)
+
+// Origin describes where the value of a variable came from.
+type Origin struct {
+	Source Source
+
+	// File is the dotenv file that supplied the value, if Source is SourceFile.
+	File string
+}
+
+func (o Origin) String() string {
+	if o.Source == SourceFile {
+		return string(o.Source) + " " + o.File
+	}
+	return string(o.Source)
+}
+
+var provenance = struct {
+	mx      sync.Mutex
+	enabled bool
+	origins map[string]Origin
+	files   map[string]fileValue
+}{
+	files: make(map[string]fileValue),
+}
+
+type fileValue struct {
+	file  string
+	value string
+}
+
+// TrackProvenance enables or disables recording of where the values read
+// by the getters and Load come from: the process environment, a dotenv file
+// or the default. It is meant for debugging values that are not what the
+// operator set, e.g. because a scope or a file shadowed them.
+// Enabling it resets the recorded provenance.
+func TrackProvenance(enabled bool) {
+	provenance.mx.Lock()
+	defer provenance.mx.Unlock()
+
+	provenance.enabled = enabled
+	provenance.origins = nil
+	if enabled {
+		provenance.origins = make(map[string]Origin)
+	}
+}
+
+// Provenance returns the origins of the values read since TrackProvenance
+// was enabled, by variable name. With Scope, the name is the one that
+// supplied the value, e.g. APP_PORT.
+func Provenance() map[string]Origin {
+	provenance.mx.Lock()
+	defer provenance.mx.Unlock()
+
+	origins := make(map[string]Origin, len(provenance.origins))
+	for variable, o := range provenance.origins {
+		origins[variable] = o
+	}
+	return origins
+}
+
+// recordEnv records that the value of the variable was read
+// from the environment.
+func recordEnv(variable string) {
+	variable = strings.TrimPrefix(variable, "$")
+
+	provenance.mx.Lock()
+	defer provenance.mx.Unlock()
+
+	if !provenance.enabled {
+		return
+	}
+	o := Origin{Source: SourceEnv}
+	if fv, ok := provenance.files[variable]; ok && fv.value == os.Getenv(variable) {
+		o = Origin{Source: SourceFile, File: fv.file}
+	}
+	provenance.origins[variable] = o
+}
+
+// recordDefault records that the variable got the default value.
+func recordDefault(variable string) {
+	variable = strings.TrimPrefix(variable, "$")
+
+	provenance.mx.Lock()
+	defer provenance.mx.Unlock()
+
+	if provenance.enabled {
+		provenance.origins[variable] = Origin{Source: SourceDefault}
+	}
+}
+
+// recordFile remembers that the variable was set from the dotenv file.
+func recordFile(variable, value, file string) {
+	provenance.mx.Lock()
+	provenance.files[variable] = fileValue{file: file, value: value}
+	provenance.mx.Unlock()
+}
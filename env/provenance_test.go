@@ -0,0 +1,71 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		filename := filepath.Join(t.TempDir(), ".env")
+		ioutil.WriteFile(filename, []byte("LEVEL=debug\nWORKERS=4\n"), 0o600)
+		os.Setenv("WORKERS", "8")
+		os.Setenv("APP_ADDR", ":9090")
+		if err := LoadFile(filename); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		TrackProvenance(true)
+		defer TrackProvenance(false)
+
+		Get("LEVEL", "info")
+		Int("WORKERS", 1)
+		Int("RETRIES", 3)
+		Scoped("APP_").Get("ADDR", ":8080")
+
+		expected := map[string]Origin{
+			"LEVEL":    {Source: SourceFile, File: filename},
+			"WORKERS":  {Source: SourceEnv},
+			"RETRIES":  {Source: SourceDefault},
+			"APP_ADDR": {Source: SourceEnv},
+		}
+		if p := Provenance(); !reflect.DeepEqual(p, expected) {
+			t.Fatalf("Expected provenance %v but got %v", expected, p)
+		}
+	})
+
+	t.Run("records Load", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("WORKERS", "4")
+		os.Setenv("GLOBAL_DB_HOST", "db")
+
+		TrackProvenance(true)
+		defer TrackProvenance(false)
+
+		var cfg testConfig
+		if err := Scoped("GLOBAL_").Load(&cfg); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		p := Provenance()
+		if o := p["GLOBAL_DB_HOST"]; o.Source != SourceEnv {
+			t.Fatalf("Expected GLOBAL_DB_HOST from env but got %v", o)
+		}
+		if o := p["HTTP_ADDR"]; o.Source != SourceDefault {
+			t.Fatalf("Expected HTTP_ADDR from default but got %v", o)
+		}
+	})
+
+	t.Run("records nothing when disabled", func(t *testing.T) {
+		os.Clearenv()
+		Get("LEVEL", "info")
+
+		if p := Provenance(); len(p) != 0 {
+			t.Fatalf("Expected empty provenance but got %v", p)
+		}
+	})
+}
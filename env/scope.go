@@ -0,0 +1,138 @@
+package env
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Scope looks up variables by trying a list of prefixes in order
+// before falling back to the bare name.
+type Scope struct {
+	prefixes []string
+}
+
+// Scoped returns a Scope with the prefixes in order of precedence.
+// It lets a service override organization-wide defaults without
+// duplicating every variable:
+//
+//	s := env.Scoped("BILLING_", "GLOBAL_")
+//	s.Get("LOG_LEVEL", "info") // BILLING_LOG_LEVEL, then GLOBAL_LOG_LEVEL, then LOG_LEVEL
+//
+// The first variable present in the environment wins, even if its value
+// is empty, so a scope can explicitly clear a value set by a wider one.
+func Scoped(prefixes ...string) Scope {
+	return Scope{prefixes: prefixes}
+}
+
+// Name returns the name of the variable that supplies the value:
+// the first prefixed variable present in the environment,
+// or the bare name if none is present.
+func (s Scope) Name(variable string) string {
+	variable = strings.TrimPrefix(variable, "$")
+	for _, prefix := range s.prefixes {
+		if _, ok := os.LookupEnv(prefix + variable); ok {
+			return prefix + variable
+		}
+	}
+	return variable
+}
+
+// Lookup returns the value of the variable in the scope
+// and whether it is present.
+func (s Scope) Lookup(variable string) (string, bool) {
+	return lookupEnv(s.Name(variable))
+}
+
+// Must is like the package-level Must, but looks up the variable in the scope.
+func (s Scope) Must(variable string) string {
+	value, ok := s.Lookup(variable)
+	if !ok {
+		panic(fmt.Sprintf("variable %s is not present in the environment", strings.Join(s.names(variable), " or ")))
+	}
+	return value
+}
+
+// Get is like the package-level Get, but looks up the variable in the scope.
+func (s Scope) Get(variable, defaultValue string) string {
+	return Get(s.Name(variable), defaultValue)
+}
+
+// MustBool is like the package-level MustBool, but looks up the variable in the scope.
+func (s Scope) MustBool(variable string) bool {
+	s.Must(variable)
+	return MustBool(s.Name(variable))
+}
+
+// Bool is like the package-level Bool, but looks up the variable in the scope.
+func (s Scope) Bool(variable string, defaultValue bool) bool {
+	return Bool(s.Name(variable), defaultValue)
+}
+
+// MustInt is like the package-level MustInt, but looks up the variable in the scope.
+func (s Scope) MustInt(variable string) int {
+	s.Must(variable)
+	return MustInt(s.Name(variable))
+}
+
+// Int is like the package-level Int, but looks up the variable in the scope.
+func (s Scope) Int(variable string, defaultValue int) int {
+	return Int(s.Name(variable), defaultValue)
+}
+
+// MustFloat64 is like the package-level MustFloat64, but looks up the variable in the scope.
+func (s Scope) MustFloat64(variable string) float64 {
+	s.Must(variable)
+	return MustFloat64(s.Name(variable))
+}
+
+// Float64 is like the package-level Float64, but looks up the variable in the scope.
+func (s Scope) Float64(variable string, defaultValue float64) float64 {
+	return Float64(s.Name(variable), defaultValue)
+}
+
+// MustDuration is like the package-level MustDuration, but looks up the variable in the scope.
+func (s Scope) MustDuration(variable string) time.Duration {
+	s.Must(variable)
+	return MustDuration(s.Name(variable))
+}
+
+// Duration is like the package-level Duration, but looks up the variable in the scope.
+func (s Scope) Duration(variable string, defaultValue time.Duration) time.Duration {
+	return Duration(s.Name(variable), defaultValue)
+}
+
+// MustURL is like the package-level MustURL, but looks up the variable in the scope.
+func (s Scope) MustURL(variable string) *url.URL {
+	s.Must(variable)
+	return MustURL(s.Name(variable))
+}
+
+// URL is like the package-level URL, but looks up the variable in the scope.
+func (s Scope) URL(variable string, defaultValue *url.URL) *url.URL {
+	return URL(s.Name(variable), defaultValue)
+}
+
+// Load is like the package-level Load, but looks up every variable in the scope.
+// Errors report the bare variable names.
+func (s Scope) Load(v interface{}) error {
+	return load(v, s.Lookup)
+}
+
+// MustLoad is like Load but panics if any variable is missing or invalid.
+func (s Scope) MustLoad(v interface{}) {
+	if err := s.Load(v); err != nil {
+		panic(err.Error())
+	}
+}
+
+func (s Scope) names(variable string) []string {
+	variable = strings.TrimPrefix(variable, "$")
+	names := make([]string, 0, len(s.prefixes)+1)
+	for _, prefix := range s.prefixes {
+		names = append(names, prefix+variable)
+	}
+	return append(names, variable)
+}
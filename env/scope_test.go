@@ -0,0 +1,64 @@
+package env
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScoped(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("APP_LEVEL", "debug")
+		os.Setenv("GLOBAL_LEVEL", "info")
+		os.Setenv("GLOBAL_WORKERS", "4")
+		os.Setenv("TIMEOUT", "5s")
+		os.Setenv("APP_DEBUG", "")
+		os.Setenv("GLOBAL_DEBUG", "true")
+
+		s := Scoped("APP_", "GLOBAL_")
+
+		if v := s.Get("LEVEL", "warn"); v != "debug" {
+			t.Fatalf("Expected value to be %q but got %q", "debug", v)
+		}
+		if v := s.MustInt("WORKERS"); v != 4 {
+			t.Fatalf("Expected value to be %v but got %v", 4, v)
+		}
+		if v := s.Duration("TIMEOUT", 0); v != time.Second*5 {
+			t.Fatalf("Expected value to be %v but got %v", time.Second*5, v)
+		}
+		if v := s.Bool("DEBUG", false); v {
+			t.Fatalf("Expected empty APP_DEBUG to shadow GLOBAL_DEBUG")
+		}
+		if v := s.Name("WORKERS"); v != "GLOBAL_WORKERS" {
+			t.Fatalf("Expected name to be %q but got %q", "GLOBAL_WORKERS", v)
+		}
+	})
+
+	t.Run("loads struct", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("APP_WORKERS", "8")
+		os.Setenv("GLOBAL_DB_HOST", "db")
+
+		var cfg testConfig
+		if err := Scoped("APP_", "GLOBAL_").Load(&cfg); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if cfg.Workers != 8 || cfg.DB.Host != "db" {
+			t.Fatalf("Unexpected config: %+v", cfg)
+		}
+	})
+
+	t.Run("panics listing all names", func(t *testing.T) {
+		os.Clearenv()
+		defer func() {
+			r := recover()
+			if r == nil || !strings.Contains(r.(string), "APP_PORT or GLOBAL_PORT or PORT") {
+				t.Fatalf("Unexpected panic: %v", r)
+			}
+		}()
+
+		Scoped("APP_", "GLOBAL_").Must("PORT")
+	})
+}
@@ -0,0 +1,318 @@
+// Package grpcserver is a simple wrapper around gRPC server
+// that applies graceful shutdown, the same way package server
+// does for http.Server.
+//
+// Typical usage:
+//
+//	grpcSrv := grpc.NewServer()
+//	pb.RegisterGreeterServer(grpcSrv, greeter)
+//
+//	srv := grpcserver.New(":9000", grpcSrv)
+//	if err := srv.Run(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// The Server implements server.Member, so it can be run
+// together with HTTP servers in server.Group.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// GRPCServer is a gRPC server. It is implemented by *grpc.Server.
+type GRPCServer interface {
+	Serve(lis net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// HealthServer is a gRPC health checking service.
+// It is implemented by *health.Server from google.golang.org/grpc/health.
+type HealthServer interface {
+	// Shutdown sets all serving statuses to NOT_SERVING
+	// and ignores future status changes.
+	Shutdown()
+}
+
+// Server is a gRPC server with graceful shutdown.
+type Server struct {
+	origin  GRPCServer
+	addr    string
+	lis     net.Listener
+	log     io.Writer
+	network string
+
+	shutdownTimeout time.Duration
+	health          HealthServer
+	drainDelay      time.Duration
+	shuttingDown    chan struct{}
+	onceShutdown    sync.Once
+
+	signals     []os.Signal
+	stopSignals chan os.Signal
+	stopSignal  os.Signal
+	stopMx      sync.Mutex
+	stopped     chan struct{}
+	onceCloser  sync.Once
+}
+
+// Option for server.
+type Option func(*Server)
+
+// Log returns an option that sets server logger.
+func Log(log io.Writer) Option {
+	return func(s *Server) {
+		s.log = log
+	}
+}
+
+// Network returns an option that sets the network the server listens on.
+// It must be one of "tcp", "tcp4" or "tcp6". Default is "tcp".
+func Network(network string) Option {
+	return func(s *Server) {
+		s.network = network
+	}
+}
+
+// ShutdownTimeout returns an option that sets the maximum duration
+// of the graceful shutdown. When it is exceeded, the server is stopped
+// forcibly. Default is 10 seconds.
+func ShutdownTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
+}
+
+// Health returns an option that sets the health service of the server.
+// On Shutdown, all its statuses are set to NOT_SERVING before the server
+// starts refusing new streams, see DrainDelay.
+func Health(h HealthServer) Option {
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
+// DrainDelay returns an option that sets how long the server keeps serving
+// after reporting NOT_SERVING and before GracefulStop sends GOAWAY.
+// This gives look-aside load balancers and Kubernetes endpoints time
+// to remove the server before its streams are refused.
+// The delay is added to the shutdown timeout. Default is zero.
+func DrainDelay(d time.Duration) Option {
+	return func(s *Server) {
+		s.drainDelay = d
+	}
+}
+
+// Signals returns an option that sets the OS signals that stop the server.
+// Default is SIGINT and SIGTERM. If no signals are given,
+// the server does not react to OS signals.
+func Signals(signals ...os.Signal) Option {
+	return func(s *Server) {
+		s.signals = signals
+	}
+}
+
+// New returns a new Server that serves srv on addr.
+func New(addr string, srv GRPCServer, opts ...Option) *Server {
+	s := &Server{
+		origin:          srv,
+		addr:            addr,
+		network:         "tcp",
+		shutdownTimeout: defaultShutdownTimeout,
+		shuttingDown:    make(chan struct{}),
+		signals:         defaultSignals,
+		stopSignals:     make(chan os.Signal, 1),
+		stopped:         make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if len(s.signals) > 0 {
+		signal.Notify(s.stopSignals, s.signals...)
+	}
+
+	return s
+}
+
+// Wrap returns a new Server that serves srv on the listener.
+func Wrap(lis net.Listener, srv GRPCServer, opts ...Option) *Server {
+	s := New(lis.Addr().String(), srv, opts...)
+	s.lis = lis
+	return s
+}
+
+// Run starts the server and blocks until ctx is cancelled, a signal
+// is received or Stop is called, then gracefully shuts the server down.
+func (s *Server) Run(ctx context.Context) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Start()
+	}()
+
+	s.waitContext(ctx)
+	shutdownErr := s.Shutdown()
+	return errors.Join(<-errc, shutdownErr)
+}
+
+// Start makes server listen and serve.
+// It blocks until server is stopped.
+// It returns nil if the server was closed by Shutdown,
+// otherwise it returns the serve error.
+func (s *Server) Start() error {
+	lis := s.lis
+	if lis == nil {
+		var err error
+		lis, err = net.Listen(s.network, s.addr)
+		if err != nil {
+			s.logMessage("%s\n", err)
+			s.Stop()
+			return err
+		}
+	}
+
+	s.logMessage("Start listening @ %s\n", s.addr)
+	err := s.origin.Serve(lis)
+
+	select {
+	case <-s.shuttingDown:
+		s.logMessage("Server closed.\n")
+		return nil
+	default:
+	}
+
+	if err != nil {
+		s.logMessage("%s\n", err)
+	}
+	s.Stop() // just to ensure everything is cleaned.
+	return err
+}
+
+// Wait blocks until SIGINT or SIGTERM is received,
+// or another stop signal if configured with Signals option.
+// Stop() can be called to unblock manually.
+func (s *Server) Wait() {
+	s.waitContext(context.Background())
+}
+
+func (s *Server) waitContext(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case sig := <-s.stopSignals:
+		s.stopMx.Lock()
+		s.stopSignal = sig
+		s.stopMx.Unlock()
+	case <-s.stopped:
+	}
+}
+
+// StopSignal returns the signal that stopped the server,
+// or nil if the server was not stopped by a signal.
+func (s *Server) StopSignal() os.Signal {
+	s.stopMx.Lock()
+	defer s.stopMx.Unlock()
+	return s.stopSignal
+}
+
+// Stop unblocks waiting server and stops listening for OS signals.
+func (s *Server) Stop() {
+	s.onceCloser.Do(func() {
+		signal.Stop(s.stopSignals)
+		close(s.stopped)
+	})
+}
+
+// InjectSignal delivers sig to the server as if it was sent by the OS.
+// It is meant for tests that need to simulate SIGINT or SIGTERM
+// without signalling the whole test process.
+func (s *Server) InjectSignal(sig os.Signal) {
+	select {
+	case <-s.stopped:
+	case s.stopSignals <- sig:
+	default:
+	}
+}
+
+// Shutdown tries to gracefully shutdown server.
+// If the shutdown timeout is exceeded, the server is stopped forcibly
+// and the context error is returned.
+func (s *Server) Shutdown() error {
+	return s.ShutdownContext(context.Background())
+}
+
+// ShutdownContext is like Shutdown, but the shutdown deadline
+// is the earliest of the ctx deadline and the shutdown timeout
+// plus the drain delay.
+func (s *Server) ShutdownContext(ctx context.Context) error {
+	s.logMessage("Shutdown server...\n")
+	s.Stop() // in case shutdown is triggered by a signal from os.
+	s.onceShutdown.Do(func() {
+		close(s.shuttingDown)
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, s.drainDelay+s.shutdownTimeout)
+	defer cancel()
+
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+	s.waitDrainDelay(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		s.origin.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logMessage("Server gracefully shut down.\n")
+		return nil
+	case <-ctx.Done():
+		s.logMessage("Server graceful shutdown failed: %s\n", ctx.Err())
+		s.origin.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// waitDrainDelay blocks for the drain delay or until ctx is done.
+func (s *Server) waitDrainDelay(ctx context.Context) {
+	if s.drainDelay <= 0 {
+		return
+	}
+
+	s.logMessage("Wait %s for load balancers to stop routing traffic...\n", s.drainDelay)
+	t := time.NewTimer(s.drainDelay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+func (s *Server) logMessage(format string, args ...interface{}) {
+	if s.log == nil {
+		return
+	}
+
+	fmt.Fprintf(s.log, format, args...)
+}
+
+const (
+	defaultShutdownTimeout = time.Second * 10
+)
+
+var defaultSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
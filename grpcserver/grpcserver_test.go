@@ -0,0 +1,169 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+var _ server.Member = (*Server)(nil)
+
+func TestServer(t *testing.T) {
+	t.Run("Should stop gracefully", func(t *testing.T) {
+		fake := newFakeGRPCServer(0)
+		srv := New("127.0.0.1:0", fake, Signals())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- srv.Run(ctx)
+		}()
+		<-fake.serving
+
+		cancel()
+		if err := <-errc; err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if !fake.gracefullyStopped() {
+			t.Fatalf("Expected server to be stopped gracefully")
+		}
+	})
+
+	t.Run("Should stop forcibly after timeout", func(t *testing.T) {
+		fake := newFakeGRPCServer(time.Second * 5)
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		srv := Wrap(lis, fake, Signals(), ShutdownTimeout(time.Millisecond*50))
+
+		go srv.Start()
+		<-fake.serving
+
+		err = srv.Shutdown()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected error %v but got %v", context.DeadlineExceeded, err)
+		}
+		if fake.gracefullyStopped() {
+			t.Fatalf("Expected server to be stopped forcibly")
+		}
+	})
+
+	t.Run("Should report NOT_SERVING before stopping", func(t *testing.T) {
+		fake := newFakeGRPCServer(0)
+		health := &fakeHealthServer{}
+		srv := New("127.0.0.1:0", fake, Signals(), Health(health), DrainDelay(time.Millisecond*100))
+
+		go srv.Start()
+		<-fake.serving
+
+		start := time.Now()
+		if err := srv.Shutdown(); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if health.shutdownAt.IsZero() {
+			t.Fatalf("Expected health to be set to NOT_SERVING")
+		}
+		stoppedAt := fake.stoppedAt()
+		if stoppedAt.Sub(health.shutdownAt) < time.Millisecond*100 || stoppedAt.Sub(start) < time.Millisecond*100 {
+			t.Fatalf("Expected GracefulStop to be called after the drain delay")
+		}
+	})
+
+	t.Run("Should return listen error", func(t *testing.T) {
+		srv := New("127.0.0.1:0", newFakeGRPCServer(0), Signals(), Network("bogus"))
+		if err := srv.Run(context.Background()); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+// fakeGRPCServer mimics *grpc.Server: GracefulStop waits for in-flight
+// RPCs, which take drainTime, unless Stop is called.
+type fakeGRPCServer struct {
+	serving   chan struct{}
+	drainTime time.Duration
+
+	mx       sync.Mutex
+	lis      net.Listener
+	graceful bool
+	stopAt   time.Time
+	stop     chan struct{}
+	once     sync.Once
+}
+
+func newFakeGRPCServer(drainTime time.Duration) *fakeGRPCServer {
+	return &fakeGRPCServer{
+		serving:   make(chan struct{}),
+		drainTime: drainTime,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (f *fakeGRPCServer) Serve(lis net.Listener) error {
+	f.mx.Lock()
+	f.lis = lis
+	f.mx.Unlock()
+	close(f.serving)
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+	}
+}
+
+func (f *fakeGRPCServer) GracefulStop() {
+	f.mx.Lock()
+	f.stopAt = time.Now()
+	f.mx.Unlock()
+	f.closeListener()
+	select {
+	case <-time.After(f.drainTime):
+		f.mx.Lock()
+		f.graceful = true
+		f.mx.Unlock()
+	case <-f.stop:
+	}
+}
+
+func (f *fakeGRPCServer) Stop() {
+	f.closeListener()
+	f.once.Do(func() { close(f.stop) })
+}
+
+func (f *fakeGRPCServer) closeListener() {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if f.lis != nil {
+		f.lis.Close()
+	}
+}
+
+func (f *fakeGRPCServer) gracefullyStopped() bool {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.graceful
+}
+
+func (f *fakeGRPCServer) stoppedAt() time.Time {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.stopAt
+}
+
+// fakeHealthServer mimics *health.Server.
+type fakeHealthServer struct {
+	shutdownAt time.Time
+}
+
+func (h *fakeHealthServer) Shutdown() {
+	h.shutdownAt = time.Now()
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxConnections returns an option that limits the number of simultaneously
+// open connections. When the limit is reached, the server stops accepting
+// until a connection is closed, so the kernel backlog queues new clients
+// instead of the server running out of file descriptors.
+// Default is zero, which means no limit.
+func MaxConnections(n int) Option {
+	return func(s *Server) {
+		s.maxConns = n
+	}
+}
+
+// AcceptStats are counters of the accept loop of the server.
+type AcceptStats struct {
+	// Accepted is the number of accepted connections.
+	Accepted uint64
+
+	// Errors is the number of temporary accept errors, like EMFILE
+	// or ECONNABORTED, the server has backed off on.
+	Errors uint64
+
+	// Open is the number of connections currently open.
+	// It is tracked only if MaxConnections is set.
+	Open int
+}
+
+// AcceptStats returns the counters of the accept loop.
+// They are zero until Ready is closed.
+func (s *Server) AcceptStats() AcceptStats {
+	select {
+	case <-s.ready:
+	default:
+		return AcceptStats{}
+	}
+
+	st := AcceptStats{
+		Accepted: s.accept.accepted.Load(),
+		Errors:   s.accept.errors.Load(),
+	}
+	if s.accept.sem != nil {
+		st.Open = len(s.accept.sem)
+	}
+	return st
+}
+
+const (
+	minAcceptBackoff = time.Millisecond * 5
+	maxAcceptBackoff = time.Second
+)
+
+// acceptListener limits open connections and backs off on temporary
+// accept errors, retrying them instead of returning them to http.Server.
+type acceptListener struct {
+	net.Listener
+	s *Server
+
+	sem       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	accepted atomic.Uint64
+	errors   atomic.Uint64
+}
+
+func (s *Server) newAcceptListener(ln net.Listener) *acceptListener {
+	l := &acceptListener{
+		Listener: ln,
+		s:        s,
+		done:     make(chan struct{}),
+	}
+	if s.maxConns > 0 {
+		l.sem = make(chan struct{}, s.maxConns)
+	}
+	return l
+}
+
+func (l *acceptListener) Accept() (net.Conn, error) {
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-l.done:
+			return nil, net.ErrClosed
+		}
+	}
+
+	var delay time.Duration
+	for {
+		c, err := l.Listener.Accept()
+		if err == nil {
+			l.accepted.Add(1)
+			if l.sem == nil {
+				return c, nil
+			}
+			return &limitedConn{Conn: c, release: l.release}, nil
+		}
+
+		if !isTemporaryAcceptError(err) {
+			if l.sem != nil {
+				l.release()
+			}
+			return nil, err
+		}
+
+		l.errors.Add(1)
+		if delay == 0 {
+			delay = minAcceptBackoff
+		} else if delay *= 2; delay > maxAcceptBackoff {
+			delay = maxAcceptBackoff
+		}
+		l.s.logMessage("Accept error: %s; retrying in %s\n", err, delay)
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-l.done:
+			t.Stop()
+			if l.sem != nil {
+				l.release()
+			}
+			return nil, net.ErrClosed
+		}
+	}
+}
+
+func (l *acceptListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+func (l *acceptListener) release() {
+	<-l.sem
+}
+
+// limitedConn releases its slot of the connection limit on close.
+type limitedConn struct {
+	net.Conn
+	release   func()
+	closeOnce sync.Once
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(c.release)
+	return err
+}
+
+// isTemporaryAcceptError reports whether the accept error is caused by
+// a transient condition, like running out of file descriptors or a client
+// resetting the connection before it was accepted.
+func isTemporaryAcceptError(err error) bool {
+	for _, target := range temporaryAcceptErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	var ne interface{ Temporary() bool }
+	return errors.As(err, &ne) && ne.Temporary()
+}
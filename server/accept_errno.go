@@ -0,0 +1,18 @@
+//go:build !plan9
+
+package server
+
+import (
+	"syscall"
+)
+
+// temporaryAcceptErrors are the errors of accept caused by a transient
+// condition, see isTemporaryAcceptError.
+var temporaryAcceptErrors = []error{
+	syscall.EMFILE,
+	syscall.ENFILE,
+	syscall.ENOBUFS,
+	syscall.ENOMEM,
+	syscall.ECONNABORTED,
+	syscall.ECONNRESET,
+}
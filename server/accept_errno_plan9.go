@@ -0,0 +1,5 @@
+package server
+
+// temporaryAcceptErrors is empty on plan9, which reports errors as strings
+// rather than errno values; only the Temporary method is checked there.
+var temporaryAcceptErrors []error
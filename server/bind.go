@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Bind returns an option that makes the server also serve the handler
+// on addr, e.g. an internal ops API on the loopback interface next to
+// the public API:
+//
+//	srv := server.New(":8080", api, server.Bind("127.0.0.1:9090", ops))
+//
+// The bound handler does not get the middlewares added with Use, so it
+// can have its own, e.g. composed with middleware.Chain. It shares the
+// lifecycle of the server: it starts listening on Start, which fails
+// if addr cannot be listened on, its requests are counted in DrainStatus,
+// and it is shut down along with the server. Timeouts and TLS settings
+// are the ones of the server.
+//
+// Bind may be used repeatedly. It is a lighter alternative to running
+// separate servers in a Group.
+func Bind(addr string, handler http.Handler) Option {
+	return func(s *Server) {
+		s.binds = append(s.binds, &binding{addr: addr, handler: handler})
+	}
+}
+
+type binding struct {
+	addr    string
+	handler http.Handler
+	srv     *http.Server
+	ln      net.Listener
+}
+
+// BindAddrs returns the addresses the bound handlers listen on,
+// in the order of Bind options. It returns nil until Ready is closed.
+func (s *Server) BindAddrs() []net.Addr {
+	select {
+	case <-s.ready:
+	default:
+		return nil
+	}
+
+	addrs := make([]net.Addr, len(s.binds))
+	for i, b := range s.binds {
+		addrs[i] = b.ln.Addr()
+	}
+	return addrs
+}
+
+// listenBinds listens on the addresses of the bound handlers.
+// If any of them fails, the listeners opened so far are closed.
+func (s *Server) listenBinds() error {
+	for i, b := range s.binds {
+		ln, err := s.listen(b.addr)
+		if err != nil {
+			for _, opened := range s.binds[:i] {
+				opened.ln.Close()
+			}
+			return err
+		}
+		b.ln = ln
+		b.srv = &http.Server{
+			Addr:              b.addr,
+			Handler:           s.drain.middleware(b.handler),
+			TLSConfig:         s.origin.TLSConfig.Clone(),
+			ReadTimeout:       s.origin.ReadTimeout,
+			ReadHeaderTimeout: s.origin.ReadHeaderTimeout,
+			WriteTimeout:      s.origin.WriteTimeout,
+			IdleTimeout:       s.origin.IdleTimeout,
+			MaxHeaderBytes:    s.origin.MaxHeaderBytes,
+			ErrorLog:          s.origin.ErrorLog,
+		}
+	}
+	return nil
+}
+
+// serveBinds serves the bound handlers. If any of them fails,
+// the server is stopped.
+func (s *Server) serveBinds() {
+	for _, b := range s.binds {
+		go func(b *binding) {
+			s.logMessage("Start listening @ %s\n", b.addr)
+			var err error
+			if s.isTLS() {
+				err = b.srv.ServeTLS(b.ln, s.certFile, s.keyFile)
+			} else {
+				err = b.srv.Serve(b.ln)
+			}
+			if err != http.ErrServerClosed {
+				s.logMessage("Server @ %s failed: %s\n", b.addr, err)
+				s.Stop()
+			}
+		}(b)
+	}
+}
+
+// shutdownBinds gracefully shuts the bound handlers down concurrently.
+func (s *Server) shutdownBinds(ctx context.Context) error {
+	errs := make([]error, len(s.binds))
+	var wg sync.WaitGroup
+	for i, b := range s.binds {
+		if b.srv == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, b *binding) {
+			defer wg.Done()
+			if err := b.srv.Shutdown(ctx); err != nil {
+				s.logMessage("Server @ %s graceful shutdown failed: %s\n", b.addr, err)
+				errs[i] = err
+			}
+		}(i, b)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
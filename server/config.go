@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Config is the effective configuration of the server.
+// It is logged on Start, so misconfigurations are visible immediately.
+// TLS certificate and key paths are never included.
+// The JSON keys match the ones of String; durations are in nanoseconds.
+type Config struct {
+	Addr              string        `json:"addr"`
+	Network           string        `json:"network"`
+	TLS               bool          `json:"tls"`
+	AutoCert          bool          `json:"autocert"`
+	ReadTimeout       time.Duration `json:"read_timeout"`
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout"`
+	WriteTimeout      time.Duration `json:"write_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout"`
+	ShutdownTimeout   time.Duration `json:"shutdown_timeout"`
+	DrainDelay        time.Duration `json:"drain_delay"`
+	MaxConnections    int           `json:"max_connections"`
+	AdminAddr         string        `json:"admin_addr,omitempty"`
+	Binds             []string      `json:"binds,omitempty"`
+	Health            bool          `json:"health"`
+	Metrics           bool          `json:"metrics"`
+	DevMode           bool          `json:"dev_mode"`
+	Middlewares       []string      `json:"middlewares,omitempty"` // Scoped ones are suffixed with "@pattern".
+}
+
+// String returns the config as a single line of key=value pairs.
+func (c Config) String() string {
+	return fmt.Sprintf(
+		"addr=%s network=%s tls=%t autocert=%t read_timeout=%s read_header_timeout=%s "+
+			"write_timeout=%s idle_timeout=%s shutdown_timeout=%s drain_delay=%s "+
+			"max_connections=%d admin_addr=%s binds=[%s] health=%t metrics=%t dev_mode=%t middlewares=[%s]",
+		quoteEmpty(c.Addr), c.Network, c.TLS, c.AutoCert, c.ReadTimeout, c.ReadHeaderTimeout,
+		c.WriteTimeout, c.IdleTimeout, c.ShutdownTimeout, c.DrainDelay, c.MaxConnections,
+		quoteEmpty(c.AdminAddr), strings.Join(c.Binds, ","), c.Health, c.Metrics, c.DevMode, strings.Join(c.Middlewares, ","),
+	)
+}
+
+// Config returns the effective configuration of the server.
+func (s *Server) Config() Config {
+	c := Config{
+		Addr:              s.origin.Addr,
+		Network:           s.network,
+		TLS:               s.isTLS(),
+		AutoCert:          s.challenge != nil,
+		ReadTimeout:       s.origin.ReadTimeout,
+		ReadHeaderTimeout: s.origin.ReadHeaderTimeout,
+		WriteTimeout:      s.origin.WriteTimeout,
+		IdleTimeout:       s.origin.IdleTimeout,
+		ShutdownTimeout:   s.shutdownTimeout,
+		DrainDelay:        s.drainDelay,
+		MaxConnections:    s.maxConns,
+		AdminAddr:         s.adminAddr,
+		Health:            s.health,
+		Metrics:           s.metrics != nil,
+		DevMode:           s.dev,
+	}
+	for _, b := range s.binds {
+		c.Binds = append(c.Binds, b.addr)
+	}
+	for _, mw := range s.middlewares {
+		name := funcName(mw.fn)
+		if mw.name != "" {
+			name += "#" + mw.name
+		}
+		if mw.prefix != "" {
+			name += "@" + mw.prefix
+		}
+		c.Middlewares = append(c.Middlewares, name)
+	}
+	return c
+}
+
+// funcName returns a short name of the function that created the middleware,
+// e.g. "middleware.Logger".
+func funcName(fn interface{}) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	// Strip closure suffixes like ".func1".
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return name
+}
+
+func quoteEmpty(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
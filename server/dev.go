@@ -0,0 +1,134 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+)
+
+// DevMode returns an option that tunes the server for local development:
+//
+//   - logging goes to stderr unless Log option is given, every request
+//     is logged, and the startup config is printed as a multi-line banner;
+//   - responses are sent with "Cache-Control: no-store" and without
+//     the Strict-Transport-Security header, so browsers do not stick
+//     to stale assets or HTTPS;
+//   - pprof handlers are served under /debug/pprof/ on the admin server,
+//     or on the main server if the admin server is not enabled.
+//
+// Combine it with SwapOn to reload the handler after a rebuild.
+func DevMode() Option {
+	return func(s *Server) {
+		s.dev = true
+	}
+}
+
+// SwapOn returns an option that makes the server replace its handler
+// with every handler received from the channel, e.g. after a rebuild
+// of templates or a plugin. The middlewares given with Use are applied
+// to the new handler as well. See also SwapHandler.
+func SwapOn(handlers <-chan http.Handler) Option {
+	return func(s *Server) {
+		s.swaps = handlers
+	}
+}
+
+// SwapHandler replaces the server handler. In-flight requests
+// are finished by the old handler, new requests go to the new one.
+// It is safe to call SwapHandler while the server is running.
+func (s *Server) SwapHandler(h http.Handler) {
+	s.handler.Store(handlerBox{h})
+}
+
+type handlerBox struct {
+	http.Handler
+}
+
+// currentHandler returns the handler set by SwapHandler.
+func (s *Server) currentHandler() http.Handler {
+	return s.handler.Load().(handlerBox).Handler
+}
+
+// watchSwaps swaps the handler with ones from s.swaps until the server stops.
+func (s *Server) watchSwaps() {
+	for {
+		select {
+		case h, ok := <-s.swaps:
+			if !ok {
+				return
+			}
+			s.logMessage("Swap handler.\n")
+			s.SwapHandler(h)
+		case <-s.stopped:
+			return
+		}
+	}
+}
+
+// devMiddleware logs requests and disables caching headers.
+func (s *Server) devMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		dw := &devWriter{statusWriter: statusWriter{ResponseWriter: w}}
+		next.ServeHTTP(dw, req)
+		s.logMessage("%s %s %d %s\n", req.Method, req.URL.Path, dw.Status(), time.Since(start))
+	})
+}
+
+// devWriter removes caching and HSTS headers from the response.
+type devWriter struct {
+	statusWriter
+	wroteHeader bool
+}
+
+func (w *devWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= 200 {
+		w.wroteHeader = true
+		w.Header().Del("Strict-Transport-Security")
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.statusWriter.WriteHeader(code)
+}
+
+func (w *devWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.statusWriter.Write(p)
+}
+
+// pprofMux returns a handler serving pprof under /debug/pprof/.
+func pprofMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// pprofMiddleware serves pprof on the main server.
+func pprofMiddleware(next http.Handler) http.Handler {
+	mux := pprofMux()
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/debug/pprof/") {
+			mux.ServeHTTP(w, req)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Pretty returns the config as a multi-line banner.
+func (c Config) Pretty() string {
+	var b strings.Builder
+	b.WriteString("Server config:\n")
+	for _, kv := range strings.Split(c.String(), " ") {
+		if i := strings.Index(kv, "="); i >= 0 {
+			b.WriteString("  " + kv[:i] + ": " + kv[i+1:] + "\n")
+		}
+	}
+	return b.String()
+}
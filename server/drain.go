@@ -0,0 +1,191 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// State is a lifecycle state of the server.
+type State string
+
+// Server states.
+const (
+	StateIdle     State = "idle"
+	StateServing  State = "serving"
+	StateLameDuck State = "lame-duck"
+	StateDraining State = "draining"
+	StateStopped  State = "stopped"
+)
+
+// DrainStatus is a snapshot of the server drain progress.
+type DrainStatus struct {
+	State State `json:"state"`
+
+	// InFlight is the number of requests being currently handled.
+	InFlight int `json:"in_flight"`
+
+	// InFlightByProtocol is the number of requests being currently handled
+	// per protocol, e.g. "HTTP/1.1" or "HTTP/2.0".
+	InFlightByProtocol map[string]int `json:"in_flight_by_protocol"`
+
+	// OldestInFlight is the age of the oldest in-flight request, in seconds.
+	OldestInFlight float64 `json:"oldest_in_flight_seconds"`
+
+	// RemainingDeadline is the time left until the graceful shutdown
+	// deadline, in seconds. It is zero unless the server is draining.
+	RemainingDeadline float64 `json:"remaining_deadline_seconds"`
+}
+
+// drainTracker keeps track of the server state and in-flight requests.
+type drainTracker struct {
+	mx       sync.Mutex
+	state    State
+	deadline time.Time
+	nextID   uint64
+	inFlight map[uint64]inFlightRequest
+}
+
+type inFlightRequest struct {
+	started time.Time
+	proto   string
+}
+
+func newDrainTracker() *drainTracker {
+	return &drainTracker{
+		state:    StateIdle,
+		inFlight: make(map[uint64]inFlightRequest),
+	}
+}
+
+func (t *drainTracker) setState(state State) {
+	t.mx.Lock()
+	t.state = state
+	t.mx.Unlock()
+}
+
+func (t *drainTracker) drain(deadline time.Time) {
+	t.mx.Lock()
+	t.state = StateDraining
+	t.deadline = deadline
+	t.mx.Unlock()
+}
+
+// enterLameDuck switches a serving server to lame-duck mode.
+// It reports whether the server is in lame-duck mode.
+func (t *drainTracker) enterLameDuck() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.state == StateServing {
+		t.state = StateLameDuck
+	}
+	return t.state == StateLameDuck
+}
+
+// leaveLameDuck switches a server in lame-duck mode back to serving.
+// It reports whether the state has changed.
+func (t *drainTracker) leaveLameDuck() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.state != StateLameDuck {
+		return false
+	}
+	t.state = StateServing
+	return true
+}
+
+// begin registers an in-flight request and reports
+// whether the server is in lame-duck mode.
+func (t *drainTracker) begin(proto string) (uint64, bool) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.nextID++
+	t.inFlight[t.nextID] = inFlightRequest{started: time.Now(), proto: proto}
+	return t.nextID, t.state == StateLameDuck
+}
+
+func (t *drainTracker) end(id uint64) {
+	t.mx.Lock()
+	delete(t.inFlight, id)
+	t.mx.Unlock()
+}
+
+func (t *drainTracker) status() DrainStatus {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	now := time.Now()
+	st := DrainStatus{
+		State:              t.state,
+		InFlight:           len(t.inFlight),
+		InFlightByProtocol: make(map[string]int),
+	}
+	for _, r := range t.inFlight {
+		st.InFlightByProtocol[r.proto]++
+		if age := now.Sub(r.started).Seconds(); age > st.OldestInFlight {
+			st.OldestInFlight = age
+		}
+	}
+	if t.state == StateDraining && t.deadline.After(now) {
+		st.RemainingDeadline = t.deadline.Sub(now).Seconds()
+	}
+	return st
+}
+
+// middleware returns handler that tracks in-flight requests
+// and asks HTTP/1 clients to close connections in lame-duck mode.
+func (t *drainTracker) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id, lameDuck := t.begin(req.Proto)
+		defer t.end(id)
+		if lameDuck && req.ProtoMajor == 1 {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// DrainStatus returns the current drain progress of the server.
+func (s *Server) DrainStatus() DrainStatus {
+	return s.drain.status()
+}
+
+// DrainStatusHandler returns a handler that reports DrainStatus as JSON.
+// It is served at /admin/drain-status by the admin server.
+func (s *Server) DrainStatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.DrainStatus())
+	})
+}
+
+// ShutdownHandler returns a handler that stops the server on POST,
+// as a stop signal does: Run and Wait return, and the server drains.
+// It responds 202 Accepted with DrainStatus as JSON.
+// It is served at /admin/shutdown by the admin server.
+func (s *Server) ShutdownHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.logMessage("Shutdown requested by %s", req.RemoteAddr)
+		s.Stop()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(s.DrainStatus())
+	})
+}
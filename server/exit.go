@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+)
+
+// ErrForced is returned by Shutdown and Run when a stop signal is received
+// again during the graceful shutdown, which aborts waiting for in-flight
+// requests and shutdown hooks.
+var ErrForced = errors.New("shutdown forced by signal")
+
+// Exit codes returned by ExitCode.
+const (
+	ExitOK           = 0
+	ExitServeError   = 1
+	ExitDrainTimeout = 2
+	ExitForced       = 130
+)
+
+// ExitCode maps the error returned by Run, Start or Shutdown
+// to the exit code of the app, so services exit consistently:
+//
+//	os.Exit(server.ExitCode(srv.Run(ctx)))
+//
+// It returns ExitOK for nil, ExitForced if the shutdown was forced by
+// a signal, ExitDrainTimeout if the graceful shutdown did not complete
+// in time, and ExitServeError otherwise.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrForced):
+		return ExitForced
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitDrainTimeout
+	default:
+		return ExitServeError
+	}
+}
+
+// watchForce cancels ctx with ErrForced if a stop signal is received
+// before ctx is done.
+func (s *Server) watchForce(ctx context.Context, force context.CancelCauseFunc) {
+	if len(s.signals) > 0 {
+		signal.Notify(s.force, s.signals...)
+	}
+
+	go func() {
+		defer signal.Stop(s.force)
+
+		select {
+		case sig := <-s.force:
+			s.logMessage("Received %s, force shutdown\n", sig)
+			force(ErrForced)
+		case <-ctx.Done():
+		}
+	}()
+}
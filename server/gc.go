@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// gcTuning is the GC configuration applied at Start.
+type gcTuning struct {
+	percent     int
+	percentSet  bool
+	memoryLimit int64
+	ballast     int
+}
+
+// GCPercent returns an option that sets the GC target percentage at Start,
+// like the GOGC environment variable. If GOGC is set, it wins, so operators
+// can always override the value baked into the app.
+func GCPercent(percent int) Option {
+	return func(s *Server) {
+		s.gc.percent = percent
+		s.gc.percentSet = true
+	}
+}
+
+// MemoryLimit returns an option that sets the soft memory limit in bytes
+// at Start, like the GOMEMLIMIT environment variable. If GOMEMLIMIT is set,
+// it wins. Setting the limit close to the container memory limit lets
+// the GC run less often under bursty load without risking OOM.
+func MemoryLimit(bytes int64) Option {
+	return func(s *Server) {
+		s.gc.memoryLimit = bytes
+	}
+}
+
+// Ballast returns an option that allocates a ballast of the given size
+// at Start and keeps it as long as the server. The ballast raises the heap size
+// the GC targets, so small heaps are not collected too often. The memory
+// is never touched, so it does not add to the resident set size.
+//
+// Prefer MemoryLimit with Go 1.19 and later; the ballast is for the cases
+// where a memory limit is not known.
+func Ballast(bytes int) Option {
+	return func(s *Server) {
+		s.gc.ballast = bytes
+	}
+}
+
+// tuneGC applies the GC configuration and logs what was applied.
+func (s *Server) tuneGC() {
+	var applied []string
+
+	if s.gc.percentSet {
+		if v, ok := os.LookupEnv("GOGC"); ok {
+			applied = append(applied, "gogc="+v+" (from GOGC)")
+		} else {
+			debug.SetGCPercent(s.gc.percent)
+			applied = append(applied, fmt.Sprintf("gogc=%d", s.gc.percent))
+		}
+	}
+	if s.gc.memoryLimit > 0 {
+		if v, ok := os.LookupEnv("GOMEMLIMIT"); ok {
+			applied = append(applied, "memory_limit="+v+" (from GOMEMLIMIT)")
+		} else {
+			debug.SetMemoryLimit(s.gc.memoryLimit)
+			applied = append(applied, fmt.Sprintf("memory_limit=%d", s.gc.memoryLimit))
+		}
+	}
+	if s.gc.ballast > 0 {
+		s.ballast = make([]byte, s.gc.ballast)
+		applied = append(applied, fmt.Sprintf("ballast=%d", s.gc.ballast))
+	}
+
+	if len(applied) > 0 {
+		s.logMessage("GC tuning: %s\n", strings.Join(applied, " "))
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// Member is a server that can be run as a part of Group.
+// *Server implements it; to add an *http.Server, wrap it with Wrap.
+type Member interface {
+	// Start serves and blocks until the member is shut down or fails.
+	Start() error
+
+	// ShutdownContext gracefully shuts the member down
+	// within the ctx deadline.
+	ShutdownContext(ctx context.Context) error
+}
+
+// Group runs several servers with one lifecycle, e.g. an API server
+// and a metrics server:
+//
+//	g := server.NewGroup()
+//	g.Add(
+//	    server.New(":8080", api),
+//	    server.New(":9090", metrics),
+//	)
+//	if err := g.Run(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+type Group struct {
+	members         []Member
+	shutdownTimeout time.Duration
+}
+
+// GroupOption is an option for Group.
+type GroupOption func(*Group)
+
+// GroupShutdownTimeout returns an option that sets the deadline shared by
+// all members during the group shutdown. Default is 10 seconds.
+func GroupShutdownTimeout(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.shutdownTimeout = d
+	}
+}
+
+// NewGroup returns a new empty Group.
+func NewGroup(opts ...GroupOption) *Group {
+	g := &Group{
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Add adds members to the group. It must be called before Run.
+func (g *Group) Add(members ...Member) {
+	g.members = append(g.members, members...)
+}
+
+// Run starts all members concurrently and blocks until ctx is cancelled,
+// a SIGINT or SIGTERM is received or any member stops. Then it gracefully shuts
+// down all members with the shared deadline. It returns the errors of
+// all members joined together.
+func (g *Group) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, defaultSignals...)
+	defer stop()
+
+	stopped := make(chan struct{})
+	var once sync.Once
+
+	var wg sync.WaitGroup
+	startErrs := make([]error, len(g.members))
+	for i, m := range g.members {
+		wg.Add(1)
+		go func(i int, m Member) {
+			defer wg.Done()
+			startErrs[i] = m.Start()
+			once.Do(func() { close(stopped) })
+		}(i, m)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-stopped:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), g.shutdownTimeout)
+	defer cancel()
+
+	shutdownErrs := make([]error, len(g.members))
+	var swg sync.WaitGroup
+	for i, m := range g.members {
+		swg.Add(1)
+		go func(i int, m Member) {
+			defer swg.Done()
+			shutdownErrs[i] = m.ShutdownContext(shutdownCtx)
+		}(i, m)
+	}
+	swg.Wait()
+	wg.Wait()
+
+	return errors.Join(append(startErrs, shutdownErrs...)...)
+}
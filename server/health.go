@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HealthCheck reports whether a component of the app is healthy.
+type HealthCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// Health returns an option that enables health check endpoints
+// on the server:
+//
+//	GET /healthz - liveness, fails only if a liveness check fails.
+//	GET /readyz  - readiness, fails if a readiness check fails
+//	               or the server is shutting down.
+//
+// Both endpoints respond with 200 and "ok", or with 503 and the list
+// of problems, one per line.
+func Health() Option {
+	return func(s *Server) {
+		s.health = true
+	}
+}
+
+// LivenessCheck returns an option that registers a liveness check.
+// It also enables health check endpoints, see Health.
+func LivenessCheck(name string, check HealthCheck) Option {
+	return func(s *Server) {
+		s.health = true
+		s.livenessChecks = append(s.livenessChecks, namedCheck{name, check})
+	}
+}
+
+// ReadinessCheck returns an option that registers a readiness check.
+// It also enables health check endpoints, see Health.
+func ReadinessCheck(name string, check HealthCheck) Option {
+	return func(s *Server) {
+		s.health = true
+		s.readinessChecks = append(s.readinessChecks, namedCheck{name, check})
+	}
+}
+
+// DrainDelay returns an option that sets how long the server keeps serving
+// with readiness failing before it actually shuts down. This gives load
+// balancers time to stop routing traffic to the server.
+// The delay is added to the shutdown timeout. Default is zero.
+func DrainDelay(d time.Duration) Option {
+	return func(s *Server) {
+		s.drainDelay = d
+	}
+}
+
+// healthMiddleware serves health check endpoints.
+func (s *Server) healthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/healthz":
+			s.serveHealth(w, req, s.livenessChecks, nil)
+		case "/readyz":
+			var problems []string
+			if st := s.drain.status().State; st != StateServing {
+				problems = append(problems, fmt.Sprintf("server is %s", st))
+			}
+			s.serveHealth(w, req, s.readinessChecks, problems)
+		default:
+			next.ServeHTTP(w, req)
+		}
+	})
+}
+
+func (s *Server) serveHealth(w http.ResponseWriter, req *http.Request, checks []namedCheck, problems []string) {
+	for _, c := range checks {
+		if err := c.check(req.Context()); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", c.name, err))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, strings.Join(problems, "\n"))
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// waitDrainDelay blocks for the drain delay or until ctx is done.
+func (s *Server) waitDrainDelay(ctx context.Context) {
+	if s.drainDelay <= 0 {
+		return
+	}
+
+	s.logMessage("Wait %s for load balancers to stop routing traffic...", s.drainDelay)
+	t := time.NewTimer(s.drainDelay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,187 @@
+// Package idempotency provides a registry where handlers declare whether
+// their routes are idempotent, so that retries, hedged requests and
+// deduplication are driven by route metadata instead of constants
+// scattered over clients and middlewares:
+//
+//	idempotency.Declare("POST /orders", false)
+//	idempotency.Declare("PUT /orders/{id}", true)
+//	idempotency.Declare("POST /search", true) // read-only despite POST
+//
+// Transports that retry or hedge requests ask Retryable before sending
+// a request again, and middleware.Dedup can deduplicate the requests
+// declared non-idempotent with the DedupRoutes option.
+package idempotency
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// Registry maps route patterns to their idempotency.
+// It is safe for concurrent use.
+type Registry struct {
+	mx     sync.RWMutex
+	routes []route
+}
+
+type route struct {
+	method     string // empty means any method.
+	pattern    string
+	idempotent bool
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry is the Registry used by the package-level functions.
+var DefaultRegistry = NewRegistry()
+
+// Declare declares the idempotency of the routes matching pattern
+// in DefaultRegistry. See Registry.Declare.
+func Declare(pattern string, idempotent bool) {
+	DefaultRegistry.Declare(pattern, idempotent)
+}
+
+// Idempotent reports whether the request is idempotent according
+// to DefaultRegistry. See Registry.Idempotent.
+func Idempotent(req *http.Request) bool {
+	return DefaultRegistry.Idempotent(req)
+}
+
+// Retryable reports whether the request is safe to retry or hedge
+// according to DefaultRegistry. See Registry.Retryable.
+func Retryable(req *http.Request) bool {
+	return DefaultRegistry.Retryable(req)
+}
+
+// Declare declares the idempotency of the routes matching pattern.
+// The pattern has the form "[METHOD ]PATH". Without a method, it applies
+// to all methods. In the path, "{name}" matches a single segment,
+// "{name...}" matches the rest of the path, and a trailing slash matches
+// all paths under it, like in http.ServeMux. Declaring the same pattern
+// again overrides the previous declaration.
+func (r *Registry) Declare(pattern string, idempotent bool) {
+	method, path := "", pattern
+	if m, p, ok := strings.Cut(pattern, " "); ok {
+		method, path = m, strings.TrimSpace(p)
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	for i, rt := range r.routes {
+		if rt.method == method && rt.pattern == path {
+			r.routes[i].idempotent = idempotent
+			return
+		}
+	}
+	r.routes = append(r.routes, route{method: method, pattern: path, idempotent: idempotent})
+}
+
+// Lookup returns the declared idempotency of the request to path
+// with method. It reports false if no declared pattern matches.
+// If several patterns match, the ones with a method take precedence,
+// then the most specific one wins.
+func (r *Registry) Lookup(method, path string) (idempotent, ok bool) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	best := -1
+	for _, rt := range r.routes {
+		if rt.method != "" && rt.method != method {
+			continue
+		}
+		score, match := matchRoute(rt.pattern, path)
+		if !match {
+			continue
+		}
+		if rt.method != "" {
+			score += 1 << 16
+		}
+		if score > best {
+			best, idempotent, ok = score, rt.idempotent, true
+		}
+	}
+	return idempotent, ok
+}
+
+// Idempotent reports whether the request is idempotent. The route
+// pattern matched by the server, if any, or the URL path is looked up
+// in the registry. Undeclared routes are idempotent if their method is,
+// per RFC 9110.
+func (r *Registry) Idempotent(req *http.Request) bool {
+	path := req.URL.Path
+	if pattern := serverctx.RoutePattern(req.Context()); pattern != "" {
+		path = pattern
+	}
+	if idempotent, ok := r.Lookup(req.Method, path); ok {
+		return idempotent
+	}
+	return IdempotentMethod(req.Method)
+}
+
+// Retryable reports whether the request is safe to retry or hedge:
+// it is idempotent, or it has an Idempotency-Key or X-Idempotency-Key
+// header, which lets the server detect the repeats, as in http.Transport.
+func (r *Registry) Retryable(req *http.Request) bool {
+	if req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != "" {
+		return true
+	}
+	return r.Idempotent(req)
+}
+
+// IdempotentMethod reports whether the method is idempotent per RFC 9110.
+func IdempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// matchRoute reports whether path matches pattern. The score is higher
+// for more specific patterns. A path equal to the pattern, e.g. a route
+// pattern set by a router, matches with the highest score.
+func matchRoute(pattern, path string) (int, bool) {
+	if pattern == path {
+		return 1 << 15, true
+	}
+
+	prefix := strings.HasSuffix(pattern, "/")
+	var pSegs []string
+	if p := strings.Trim(pattern, "/"); p != "" {
+		pSegs = strings.Split(p, "/")
+	}
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+
+	score := 0
+	for i, ps := range pSegs {
+		if strings.HasPrefix(ps, "{") && strings.HasSuffix(ps, "...}") {
+			return score, true
+		}
+		if i >= len(segs) {
+			return 0, false
+		}
+		switch {
+		case strings.HasPrefix(ps, "{") && strings.HasSuffix(ps, "}"):
+			if segs[i] == "" {
+				return 0, false
+			}
+			score++
+		case ps == segs[i]:
+			score += 2
+		default:
+			return 0, false
+		}
+	}
+	if len(segs) > len(pSegs) && !prefix {
+		return 0, false
+	}
+	return score, true
+}
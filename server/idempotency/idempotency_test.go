@@ -0,0 +1,64 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	reg.Declare("POST /search", true)
+	reg.Declare("/orders/{id}/pay", false)
+	reg.Declare("PUT /orders/{id}/pay", true)
+	reg.Declare("DELETE /files/", false)
+	reg.Declare("/tasks/{id}/{rest...}", false)
+	reg.Declare("PUT /orders/{id}", true)
+	reg.Declare("PUT /orders/{id}", false)
+
+	cases := []struct {
+		method string
+		target string
+		want   bool
+	}{
+		{http.MethodPost, "/search", true},
+		{http.MethodPost, "/orders", false},
+		{http.MethodGet, "/orders", true},
+		{http.MethodGet, "/orders/1/pay", false},
+		{http.MethodPut, "/orders/1/pay", true},
+		{http.MethodPut, "/orders/1", false},
+		{http.MethodDelete, "/files/a/b", false},
+		{http.MethodDelete, "/tasks/1/a/b", false},
+		{http.MethodDelete, "/other", true},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.target, nil)
+		if got := reg.Idempotent(req); got != c.want {
+			t.Fatalf("Expected %s %s idempotent to be %v but got %v", c.method, c.target, c.want, got)
+		}
+	}
+
+	t.Run("uses route pattern", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/find", nil)
+		req = req.WithContext(serverctx.WithRoutePattern(req.Context(), "/search"))
+		if !reg.Idempotent(req) {
+			t.Fatalf("Expected request to be idempotent by route pattern")
+		}
+	})
+
+	t.Run("retries requests with idempotency key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		if reg.Retryable(req) {
+			t.Fatalf("Expected request without key not to be retryable")
+		}
+		req.Header.Set("Idempotency-Key", "abc")
+		if !reg.Retryable(req) {
+			t.Fatalf("Expected request with key to be retryable")
+		}
+		if reg.Idempotent(req) {
+			t.Fatalf("Expected request with key to stay non-idempotent")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// LameDuck puts the server into lame-duck mode: it keeps serving requests,
+// but readiness fails and responses to HTTP/1 requests carry
+// "Connection: close", so load balancers and keep-alive clients move
+// to other instances. Unlike Shutdown, nothing is stopped, so an operator
+// can drain the instance for maintenance and decide later whether
+// to Resume or to shut it down.
+//
+// If d is positive, the server resumes serving after d, unless it is
+// shut down meanwhile. Otherwise it stays in lame-duck mode until Resume
+// is called. LameDuck has no effect unless the server is serving.
+func (s *Server) LameDuck(d time.Duration) {
+	s.lameDuckMx.Lock()
+	defer s.lameDuckMx.Unlock()
+
+	if !s.drain.enterLameDuck() {
+		return
+	}
+
+	if s.lameDuckTimer != nil {
+		s.lameDuckTimer.Stop()
+		s.lameDuckTimer = nil
+	}
+	if d > 0 {
+		s.lameDuckTimer = time.AfterFunc(d, s.Resume)
+		s.logMessage("Enter lame-duck mode for %s", d)
+		return
+	}
+	s.logMessage("Enter lame-duck mode")
+}
+
+// Resume makes the server leave lame-duck mode, see LameDuck.
+// It has no effect unless the server is in lame-duck mode.
+func (s *Server) Resume() {
+	s.lameDuckMx.Lock()
+	defer s.lameDuckMx.Unlock()
+
+	if s.lameDuckTimer != nil {
+		s.lameDuckTimer.Stop()
+		s.lameDuckTimer = nil
+	}
+	if s.drain.leaveLameDuck() {
+		s.logMessage("Leave lame-duck mode")
+	}
+}
+
+// LameDuckHandler returns a handler that controls lame-duck mode.
+// POST enters it, for the duration given as duration=30m in the query
+// or form, if any. DELETE resumes serving. All methods respond with
+// DrainStatus as JSON. It is served at /admin/lame-duck by the admin server.
+func (s *Server) LameDuckHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var d time.Duration
+			if v := req.FormValue("duration"); v != "" {
+				var err error
+				if d, err = time.ParseDuration(v); err != nil {
+					http.Error(w, "duration must be a valid duration, e.g. 30m", http.StatusBadRequest)
+					return
+				}
+			}
+			s.LameDuck(d)
+		case http.MethodDelete:
+			s.Resume()
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.DrainStatus())
+	})
+}
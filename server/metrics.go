@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsRecorder receives the server metrics. Implement it to export
+// the metrics to Prometheus or any other system, e.g.:
+//
+//	type promRecorder struct {
+//	    conns    prometheus.Gauge
+//	    requests *prometheus.CounterVec
+//	    drain    prometheus.Histogram
+//	}
+//
+//	func (r promRecorder) SetActiveConnections(n int) {
+//	    r.conns.Set(float64(n))
+//	}
+//	...
+//
+// The methods are called concurrently.
+type MetricsRecorder interface {
+	// SetActiveConnections is called whenever the number of open
+	// client connections changes.
+	SetActiveConnections(n int)
+
+	// IncRequests is called when a request is handled.
+	IncRequests(method string, code int)
+
+	// ObserveShutdown is called when the graceful shutdown completes.
+	ObserveShutdown(stats ShutdownStats)
+}
+
+// ShutdownStats describes the graceful shutdown of the server.
+type ShutdownStats struct {
+	// InFlight is the number of in-flight requests at shutdown start.
+	InFlight int
+
+	// Duration is the time the server took to drain.
+	Duration time.Duration
+
+	// DeadlineExceeded reports whether the server failed to drain
+	// before the shutdown deadline.
+	DeadlineExceeded bool
+}
+
+// Metrics returns an option that instruments the server with the recorder.
+func Metrics(r MetricsRecorder) Option {
+	return func(s *Server) {
+		s.metrics = r
+	}
+}
+
+// instrument sets up the metrics collection for the server.
+func (s *Server) instrument(next http.Handler) http.Handler {
+	var conns int64
+	connState := s.origin.ConnState
+	s.origin.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			s.metrics.SetActiveConnections(int(atomic.AddInt64(&conns, 1)))
+		case http.StateHijacked, http.StateClosed:
+			s.metrics.SetActiveConnections(int(atomic.AddInt64(&conns, -1)))
+		}
+		if connState != nil {
+			connState(conn, state)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, req)
+		s.metrics.IncRequests(req.Method, sw.Status())
+	})
+}
+
+// statusWriter records the response status code.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the original writer for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the response status code.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
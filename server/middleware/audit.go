@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// Audit outcomes.
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+	OutcomeError   = "error"
+)
+
+// AuditRecord is a record of an action performed through the server.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+
+	// Actor is the ID of the principal authenticated by Auth,
+	// or set with SetAuditActor. It is empty for anonymous requests.
+	Actor string `json:"actor,omitempty"`
+
+	// Action is the method and the route pattern, e.g. "DELETE /orders/{id}",
+	// or set with SetAuditAction. Without a route pattern, the path is used.
+	Action string `json:"action"`
+
+	// Outcome is one of OutcomeSuccess, OutcomeDenied (401 and 403),
+	// OutcomeFailure (other 4xx) and OutcomeError (5xx).
+	Outcome string `json:"outcome"`
+
+	Status    int    `json:"status"`
+	RequestID string `json:"request_id,omitempty"`
+	IP        string `json:"ip"`
+}
+
+// AuditSink stores audit records.
+type AuditSink interface {
+	WriteAudit(r AuditRecord) error
+}
+
+// AuditSinkFunc is a function that implements AuditSink.
+type AuditSinkFunc func(r AuditRecord) error
+
+// WriteAudit calls f(r).
+func (f AuditSinkFunc) WriteAudit(r AuditRecord) error {
+	return f(r)
+}
+
+// Audit returns a middleware that writes an audit record to sink for every
+// request, after the handler returns. Unlike Logger, it is meant for
+// compliance, so it is usually applied to the routes that change things.
+// Errors of the sink are written to errLog, if it is not nil.
+//
+// Audit should wrap Auth to record denied requests too; Auth reports
+// the actor to Audit through the request context.
+func Audit(sink AuditSink, errLog io.Writer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			entry := &auditEntry{}
+			ctx := context.WithValue(req.Context(), auditKey{}, entry)
+			rw := &responseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(rw, req.WithContext(ctx))
+
+			r := entry.record()
+			r.Time = time.Now()
+			r.Status = rw.Status()
+			r.Outcome = outcome(r.Status)
+			r.RequestID = serverctx.RequestID(ctx)
+			r.IP = clientIP(req)
+			if r.Action == "" {
+				route := serverctx.RoutePattern(ctx)
+				if route == "" {
+					route = req.URL.Path
+				}
+				r.Action = req.Method + " " + route
+			}
+
+			if err := sink.WriteAudit(r); err != nil && errLog != nil {
+				fmt.Fprintf(errLog, "audit %s: %s\n", r.Action, err)
+			}
+		})
+	}
+}
+
+// SetAuditActor sets the actor of the audit record of the request.
+// It is a no-op if the request is not audited.
+func SetAuditActor(ctx context.Context, actor string) {
+	if entry, ok := ctx.Value(auditKey{}).(*auditEntry); ok {
+		entry.mx.Lock()
+		entry.actor = actor
+		entry.mx.Unlock()
+	}
+}
+
+// SetAuditAction overrides the action of the audit record of the request.
+// It is a no-op if the request is not audited.
+func SetAuditAction(ctx context.Context, action string) {
+	if entry, ok := ctx.Value(auditKey{}).(*auditEntry); ok {
+		entry.mx.Lock()
+		entry.action = action
+		entry.mx.Unlock()
+	}
+}
+
+type auditKey struct{}
+
+// auditEntry collects the record fields known only deeper in the chain.
+type auditEntry struct {
+	mx     sync.Mutex
+	actor  string
+	action string
+}
+
+func (e *auditEntry) record() AuditRecord {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	return AuditRecord{Actor: e.actor, Action: e.action}
+}
+
+func outcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied
+	case status >= 500:
+		return OutcomeError
+	case status >= 400:
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
+
+func clientIP(req *http.Request) string {
+	if ip := serverctx.RealIP(req.Context()); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+func TestAudit(t *testing.T) {
+	var records []AuditRecord
+	sink := AuditSinkFunc(func(r AuditRecord) error {
+		records = append(records, r)
+		return nil
+	})
+	authn := APIKey("X-API-Key", func(key string) (Principal, bool) {
+		return Principal{ID: "alice"}, key == "k1"
+	})
+	handler := Chain(RequestID(), Audit(sink, nil), Auth(authn, nil))(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			SetAuditAction(req.Context(), "delete order")
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+
+	tests := []struct {
+		method  string
+		key     string
+		actor   string
+		action  string
+		outcome string
+	}{
+		{http.MethodPost, "k1", "alice", "POST /orders", OutcomeSuccess},
+		{http.MethodPost, "k2", "", "POST /orders", OutcomeDenied},
+		{http.MethodDelete, "k1", "alice", "delete order", OutcomeFailure},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.key, func(t *testing.T) {
+			records = nil
+			req := httptest.NewRequest(tc.method, "/orders", nil)
+			req.Header.Set("X-API-Key", tc.key)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(records) != 1 {
+				t.Fatalf("Expected 1 record but got %d", len(records))
+			}
+			r := records[0]
+			if r.Actor != tc.actor || r.Action != tc.action || r.Outcome != tc.outcome {
+				t.Fatalf("Unexpected record: %+v", r)
+			}
+			if r.RequestID == "" || r.IP != "192.0.2.1" {
+				t.Fatalf("Expected request ID and IP in record: %+v", r)
+			}
+		})
+	}
+
+	t.Run("uses route pattern", func(t *testing.T) {
+		records = nil
+		req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		req = req.WithContext(serverctx.WithRoutePattern(req.Context(), "/orders/{id}"))
+		Audit(sink, nil)(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(records) != 1 || records[0].Action != "GET /orders/{id}" {
+			t.Fatalf("Unexpected records: %+v", records)
+		}
+	})
+}
+
+func TestAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := OpenAuditFile(path, 150, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := f.WriteAudit(AuditRecord{Action: fmt.Sprintf("action %d", i)}); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	var actions []string
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		sc := bufio.NewScanner(file)
+		for sc.Scan() {
+			var r AuditRecord
+			if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			actions = append(actions, r.Action)
+		}
+		file.Close()
+	}
+
+	// Each record is about 100 bytes, so every file holds one record
+	// and the oldest ones are dropped.
+	if got := strings.Join(actions, ", "); got != "action 2, action 3, action 4" {
+		t.Fatalf("Unexpected records: %s", got)
+	}
+}
+
+func TestAuditFile_noBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := OpenAuditFile(path, 150, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := f.WriteAudit(AuditRecord{Action: fmt.Sprintf("action %d", i)}); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("Expected the rotated records to be kept but got %s", err)
+	}
+	if !strings.Contains(string(b), "action 0") {
+		t.Fatalf("Unexpected backup: %s", b)
+	}
+}
+
+func TestAuditFile_failedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	// A non-empty directory in place of the backup makes rename fail.
+	if err := os.MkdirAll(filepath.Join(path+".1", "dir"), 0o700); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	f, err := OpenAuditFile(path, 150, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	if err := f.WriteAudit(AuditRecord{Action: "action 0"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := f.WriteAudit(AuditRecord{Action: "action 1"}); err == nil {
+		t.Fatalf("Expected rotation error")
+	}
+	if err := f.WriteAudit(AuditRecord{Action: "action 2"}); err == nil {
+		t.Fatalf("Expected rotation error")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, action := range []string{"action 0", "action 1", "action 2"} {
+		if !strings.Contains(string(b), action) {
+			t.Fatalf("Expected %q to be written but got %s", action, b)
+		}
+	}
+}
+
+func TestAuditHTTP(t *testing.T) {
+	received := make(chan AuditRecord, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var r AuditRecord
+		json.NewDecoder(req.Body).Decode(&r)
+		received <- r
+		if r.Actor == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	sink := AuditHTTP(srv.URL, srv.Client())
+	if err := sink.WriteAudit(AuditRecord{Actor: "alice"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if r := <-received; r.Actor != "alice" {
+		t.Fatalf("Unexpected record: %+v", r)
+	}
+	if err := sink.WriteAudit(AuditRecord{}); err == nil {
+		t.Fatalf("Expected error")
+	}
+	<-received
+
+	sink = AuditHTTP(srv.URL, nil)
+	if err := sink.WriteAudit(AuditRecord{Actor: "bob"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if r := <-received; r.Actor != "bob" {
+		t.Fatalf("Unexpected record: %+v", r)
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditFile is an audit sink that appends records to a file as JSON lines.
+// When the file grows over the size limit, it is rotated: path becomes
+// path.1, path.1 becomes path.2, and so on up to the number of backups.
+// At least one backup is kept, so rotation never drops the records just
+// written. If rotation fails, the records keep being appended to path.
+type AuditFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mx   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenAuditFile opens the audit file for appending, creating it if needed.
+// Zero maxSize disables rotation. A maxBackups below 1 is treated as 1.
+func OpenAuditFile(path string, maxSize int64, maxBackups int) (*AuditFile, error) {
+	if maxBackups < 1 {
+		maxBackups = 1
+	}
+	a := &AuditFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// WriteAudit appends the record to the file.
+func (a *AuditFile) WriteAudit(r AuditRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	var rotateErr error
+	if a.f != nil && a.maxSize > 0 && a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		rotateErr = a.rotate()
+	}
+	if a.f == nil {
+		// The file was closed by a failed rotation; try to reopen it.
+		if err := a.open(); err != nil {
+			return errors.Join(rotateErr, err)
+		}
+	}
+
+	n, err := a.f.Write(line)
+	a.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if rotateErr != nil {
+		return fmt.Errorf("rotate audit file: %w", rotateErr)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (a *AuditFile) Close() error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	if a.f == nil {
+		return nil
+	}
+	err := a.f.Close()
+	a.f = nil
+	return err
+}
+
+func (a *AuditFile) open() error {
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.f, a.size = f, info.Size()
+	return nil
+}
+
+// rotate moves the file to the first backup and opens a new one.
+// Whatever fails, it reopens path, so that later writes succeed.
+func (a *AuditFile) rotate() error {
+	err := a.f.Close()
+	a.f = nil
+	if err == nil {
+		for i := a.maxBackups - 1; i > 0; i-- {
+			os.Rename(a.backup(i), a.backup(i+1))
+		}
+		err = os.Rename(a.path, a.backup(1))
+	}
+
+	if oerr := a.open(); oerr != nil {
+		return errors.Join(err, oerr)
+	}
+	return err
+}
+
+func (a *AuditFile) backup(i int) string {
+	return a.path + "." + strconv.Itoa(i)
+}
+
+// AuditHTTP returns an audit sink that sends every record as JSON
+// in a POST request to the URL, e.g. of a log collector.
+// Any response status other than 2xx is an error. If client is nil,
+// a client with a 10 seconds timeout is used.
+func AuditHTTP(url string, client *http.Client) AuditSink {
+	if client == nil {
+		client = &http.Client{Timeout: time.Second * 10}
+	}
+
+	return AuditSinkFunc(func(r AuditRecord) error {
+		body, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(ioutil.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("audit endpoint responded with %s", resp.Status)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// ErrNoCredentials is returned by authenticators
+// when the request carries no credentials they understand.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Principal is an authenticated identity: a user, a service, etc.
+type Principal struct {
+	// ID identifies the principal, e.g. a user name or a service name.
+	ID string
+
+	// Claims are arbitrary attributes of the principal, e.g. roles.
+	Claims serverctx.Claims
+}
+
+// Authenticator authenticates requests.
+type Authenticator interface {
+	// Authenticate returns the principal that made the request.
+	// It returns ErrNoCredentials if the request has no credentials
+	// for this authenticator, or another error if they are invalid.
+	Authenticate(req *http.Request) (Principal, error)
+}
+
+// AuthenticatorFunc is a function that implements Authenticator.
+type AuthenticatorFunc func(req *http.Request) (Principal, error)
+
+// Authenticate calls f(req).
+func (f AuthenticatorFunc) Authenticate(req *http.Request) (Principal, error) {
+	return f(req)
+}
+
+// Authorizer decides whether a principal may make a request.
+type Authorizer interface {
+	// Authorize returns an error if the principal is not allowed
+	// to make the request.
+	Authorize(p Principal, req *http.Request) error
+}
+
+// AuthorizerFunc is a function that implements Authorizer.
+type AuthorizerFunc func(p Principal, req *http.Request) error
+
+// Authorize calls f(p, req).
+func (f AuthorizerFunc) Authorize(p Principal, req *http.Request) error {
+	return f(p, req)
+}
+
+// Challenger is implemented by authenticators that tell the client
+// how to authenticate, via the WWW-Authenticate header.
+type Challenger interface {
+	Challenge() string
+}
+
+type principalKey struct{}
+
+// Auth returns a middleware that authenticates every request with authn
+// and authorizes it with authz. Unauthenticated requests get 401,
+// unauthorized ones get 403. If authz is nil, every authenticated
+// request is allowed.
+//
+// Handlers get the principal with GetPrincipal,
+// and its claims with serverctx.AuthClaims.
+func Auth(authn Authenticator, authz Authorizer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			p, err := authn.Authenticate(req)
+			if err != nil {
+				if c, ok := authn.(Challenger); ok {
+					w.Header().Set("WWW-Authenticate", c.Challenge())
+				}
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			SetAuditActor(req.Context(), p.ID)
+
+			if authz != nil {
+				if err := authz.Authorize(p, req); err != nil {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
+
+			ctx := context.WithValue(req.Context(), principalKey{}, p)
+			ctx = serverctx.WithClaims(ctx, p.Claims)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// GetPrincipal returns the principal authenticated by Auth middleware.
+func GetPrincipal(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// AnyOf returns an authenticator that tries the authenticators in order
+// and uses the first one that finds credentials in the request.
+func AnyOf(authns ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) (Principal, error) {
+		for _, authn := range authns {
+			p, err := authn.Authenticate(req)
+			if err != ErrNoCredentials {
+				return p, err
+			}
+		}
+		return Principal{}, ErrNoCredentials
+	})
+}
+
+// BasicAuth returns an authenticator for HTTP Basic authentication.
+// The check function validates the credentials; it should compare
+// secrets in constant time, e.g. with crypto/subtle.
+func BasicAuth(realm string, check func(user, password string) (Principal, bool)) Authenticator {
+	return basicAuth{realm: realm, check: check}
+}
+
+type basicAuth struct {
+	realm string
+	check func(user, password string) (Principal, bool)
+}
+
+func (a basicAuth) Authenticate(req *http.Request) (Principal, error) {
+	user, password, ok := req.BasicAuth()
+	if !ok {
+		return Principal{}, ErrNoCredentials
+	}
+	p, ok := a.check(user, password)
+	if !ok {
+		return Principal{}, errors.New("invalid credentials")
+	}
+	return p, nil
+}
+
+func (a basicAuth) Challenge() string {
+	return `Basic realm="` + a.realm + `"`
+}
+
+// APIKey returns an authenticator for API keys passed in the header.
+// The lookup function returns the principal owning the key.
+func APIKey(header string, lookup func(key string) (Principal, bool)) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) (Principal, error) {
+		key := req.Header.Get(header)
+		if key == "" {
+			return Principal{}, ErrNoCredentials
+		}
+		p, ok := lookup(key)
+		if !ok {
+			return Principal{}, errors.New("invalid API key")
+		}
+		return p, nil
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+func TestAuth(t *testing.T) {
+	users := BasicAuth("test", func(user, password string) (Principal, bool) {
+		if user == "alice" && password == "secret" {
+			return Principal{ID: "alice", Claims: serverctx.Claims{"role": "admin"}}, true
+		}
+		return Principal{}, false
+	})
+	keys := APIKey("X-API-Key", func(key string) (Principal, bool) {
+		if key == "k1" {
+			return Principal{ID: "bot", Claims: serverctx.Claims{"role": "reader"}}, true
+		}
+		return Principal{}, false
+	})
+	adminsOnly := AuthorizerFunc(func(p Principal, req *http.Request) error {
+		if req.Method != http.MethodGet && p.Claims["role"] != "admin" {
+			return errors.New("forbidden")
+		}
+		return nil
+	})
+
+	handler := Auth(AnyOf(users, keys), adminsOnly)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p, _ := GetPrincipal(req.Context())
+		w.Header().Set("X-Principal", p.ID)
+		w.Header().Set("X-Role", serverctx.AuthClaims(req.Context())["role"].(string))
+	}))
+
+	tests := []struct {
+		name      string
+		method    string
+		setup     func(req *http.Request)
+		code      int
+		principal string
+	}{
+		{"no credentials", http.MethodGet, func(req *http.Request) {}, http.StatusUnauthorized, ""},
+		{"invalid password", http.MethodGet, func(req *http.Request) { req.SetBasicAuth("alice", "wrong") }, http.StatusUnauthorized, ""},
+		{"basic auth", http.MethodPost, func(req *http.Request) { req.SetBasicAuth("alice", "secret") }, http.StatusOK, "alice"},
+		{"api key", http.MethodGet, func(req *http.Request) { req.Header.Set("X-API-Key", "k1") }, http.StatusOK, "bot"},
+		{"forbidden", http.MethodPost, func(req *http.Request) { req.Header.Set("X-API-Key", "k1") }, http.StatusForbidden, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", nil)
+			tc.setup(req)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Fatalf("Expected status %d but got %d", tc.code, rec.Code)
+			}
+			if p := rec.Header().Get("X-Principal"); p != tc.principal {
+				t.Fatalf("Expected principal %q but got %q", tc.principal, p)
+			}
+		})
+	}
+
+	t.Run("challenge", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Auth(users, nil)(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if c := rec.Header().Get("WWW-Authenticate"); c != `Basic realm="test"` {
+			t.Fatalf("Unexpected challenge: %q", c)
+		}
+	})
+}
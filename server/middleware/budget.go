@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TimeoutBudgetHeader is the header carrying the time in milliseconds
+// the caller is going to wait for the response.
+const TimeoutBudgetHeader = "X-Timeout-Budget"
+
+// Budget returns a middleware that sets the request context deadline to
+// the caller budget from the X-Timeout-Budget header, capped by max.
+// Zero max means no cap; without the header and the cap, the deadline
+// is not set. Requests that arrive with an exhausted budget get
+// 504 Gateway Timeout, since the caller has already given up.
+//
+// Together with BudgetTransport it keeps downstream calls and their
+// retries within the deadline of the original caller.
+func Budget(max time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			budget := max
+			if ms, err := strconv.ParseInt(req.Header.Get(TimeoutBudgetHeader), 10, 64); err == nil {
+				if d := time.Duration(ms) * time.Millisecond; budget == 0 || d < budget {
+					budget = d
+				}
+				if budget <= 0 {
+					http.Error(w, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+					return
+				}
+			}
+			if budget <= 0 {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), budget)
+			defer cancel()
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// RemainingBudget returns the time left until the ctx deadline,
+// and false if ctx has no deadline.
+func RemainingBudget(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// BudgetTransport returns a transport that sends the remaining budget
+// of the outbound request context in the X-Timeout-Budget header, so the
+// downstream service can stop working when nobody waits for the result.
+// The reserve is subtracted from the budget, leaving time to handle
+// the response. If nothing is left, the request fails immediately with
+// context.DeadlineExceeded. A nil base means http.DefaultTransport.
+//
+// Use the handler request context for outbound requests to chain budgets:
+//
+//	client := &http.Client{Transport: middleware.BudgetTransport(nil, 50*time.Millisecond)}
+//	out, _ := http.NewRequestWithContext(req.Context(), http.MethodGet, url, nil)
+//	resp, err := client.Do(out)
+func BudgetTransport(base http.RoundTripper, reserve time.Duration) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return budgetTransport{base: base, reserve: reserve}
+}
+
+type budgetTransport struct {
+	base    http.RoundTripper
+	reserve time.Duration
+}
+
+func (t budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	remaining, ok := RemainingBudget(req.Context())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	budget := remaining - t.reserve
+	if budget <= 0 {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, context.DeadlineExceeded
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), budget)
+	// RoundTrip must not modify the request, so send a copy.
+	out := req.Clone(ctx)
+	out.Header.Set(TimeoutBudgetHeader, strconv.FormatInt(budget.Milliseconds(), 10))
+
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
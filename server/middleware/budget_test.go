@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	remaining := func(header string, max time.Duration) (time.Duration, int) {
+		var left time.Duration
+		handler := Budget(max)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			left, _ = RemainingBudget(req.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(TimeoutBudgetHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return left, rec.Code
+	}
+
+	t.Run("uses caller budget", func(t *testing.T) {
+		left, _ := remaining("500", time.Second*10)
+		if left <= 0 || left > time.Millisecond*500 {
+			t.Fatalf("Expected budget of about 500ms but got %s", left)
+		}
+	})
+
+	t.Run("caps caller budget", func(t *testing.T) {
+		left, _ := remaining("60000", time.Second)
+		if left <= 0 || left > time.Second {
+			t.Fatalf("Expected budget of about 1s but got %s", left)
+		}
+	})
+
+	t.Run("sets no deadline without budget", func(t *testing.T) {
+		if left, _ := remaining("", 0); left != 0 {
+			t.Fatalf("Expected no deadline but got %s", left)
+		}
+	})
+
+	t.Run("rejects exhausted budget", func(t *testing.T) {
+		if _, code := remaining("0", 0); code != http.StatusGatewayTimeout {
+			t.Fatalf("Expected status %d but got %d", http.StatusGatewayTimeout, code)
+		}
+	})
+}
+
+func TestBudgetTransport(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received <- req.Header.Get(TimeoutBudgetHeader)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: BudgetTransport(nil, time.Millisecond*100)}
+
+	t.Run("propagates remaining budget", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+
+		ms, _ := strconv.Atoi(<-received)
+		if ms <= 0 || ms > 900 {
+			t.Fatalf("Expected budget of about 900ms but got %dms", ms)
+		}
+	})
+
+	t.Run("fails fast when budget is spent", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		_, err := client.Do(req)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected %v but got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import "net/http"
+
+// Chain composes the middlewares into one. The first middleware
+// is the outermost, i.e. it is the first to see a request:
+//
+//	Chain(RequestID(), Logger(os.Stderr))(handler)
+//
+// is equal to:
+//
+//	RequestID()(Logger(os.Stderr)(handler))
+func Chain(mws ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
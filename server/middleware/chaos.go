@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ChaosOption is an option for NewChaos.
+type ChaosOption func(*Chaos)
+
+// ChaosEnabled returns an option that enables fault injection right away.
+// Otherwise it has to be enabled with Enable or through Handler.
+func ChaosEnabled() ChaosOption {
+	return func(c *Chaos) {
+		c.enabled = true
+	}
+}
+
+// ChaosErrors returns an option that makes the share of requests given
+// by rate, from 0 to 1, fail with one of the codes chosen at random.
+// Default codes are 500 and 503. Errors and aborts, see ChaosAborts,
+// do not overlap: their rates add up, up to 1.
+func ChaosErrors(rate float64, codes ...int) ChaosOption {
+	return func(c *Chaos) {
+		c.errorRate = rate
+		if len(codes) > 0 {
+			c.codes = codes
+		}
+	}
+}
+
+// ChaosLatency returns an option that delays every request by a duration
+// uniformly distributed between min and max.
+func ChaosLatency(min, max time.Duration) ChaosOption {
+	return func(c *Chaos) {
+		c.minLatency, c.maxLatency = min, max
+	}
+}
+
+// ChaosAborts returns an option that makes the share of requests given
+// by rate, from 0 to 1, abort the connection without a response.
+func ChaosAborts(rate float64) ChaosOption {
+	return func(c *Chaos) {
+		c.abortRate = rate
+	}
+}
+
+// Chaos injects faults into requests, so the resilience of clients and
+// their retry policies can be tested against a real server.
+// It is meant for non-production environments only:
+//
+//	chaos := middleware.NewChaos(middleware.ChaosErrors(0.1), middleware.ChaosLatency(0, time.Second))
+//	srv := server.New(addr, handler,
+//	    server.Use(chaos.Middleware),
+//	    server.Admin(":9090"),
+//	    server.AdminHandle("/admin/chaos", chaos.Handler()),
+//	)
+//
+// Chaos is disabled until enabled explicitly.
+type Chaos struct {
+	mx         sync.Mutex
+	enabled    bool
+	errorRate  float64
+	codes      []int
+	minLatency time.Duration
+	maxLatency time.Duration
+	abortRate  float64
+	rand       *rand.Rand
+}
+
+// NewChaos returns a new Chaos.
+func NewChaos(opts ...ChaosOption) *Chaos {
+	c := &Chaos{
+		codes: []int{http.StatusInternalServerError, http.StatusServiceUnavailable},
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Enable enables fault injection.
+func (c *Chaos) Enable() {
+	c.setEnabled(true)
+}
+
+// Disable disables fault injection.
+func (c *Chaos) Disable() {
+	c.setEnabled(false)
+}
+
+// Enabled reports whether fault injection is enabled.
+func (c *Chaos) Enabled() bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.enabled
+}
+
+func (c *Chaos) setEnabled(enabled bool) {
+	c.mx.Lock()
+	c.enabled = enabled
+	c.mx.Unlock()
+}
+
+// Middleware injects faults into requests while Chaos is enabled.
+func (c *Chaos) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		f, ok := c.roll()
+		if !ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if f.latency > 0 {
+			select {
+			case <-time.After(f.latency):
+			case <-req.Context().Done():
+				return
+			}
+		}
+		switch {
+		case f.abort:
+			panic(http.ErrAbortHandler)
+		case f.code != 0:
+			http.Error(w, http.StatusText(f.code), f.code)
+		default:
+			next.ServeHTTP(w, req)
+		}
+	})
+}
+
+// Handler returns a handler to toggle Chaos at runtime, e.g. on the admin
+// server. GET reports the state as JSON, POST with enabled=true or
+// enabled=false in the query or form changes it.
+func (c *Chaos) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			enabled, err := strconv.ParseBool(req.FormValue("enabled"))
+			if err != nil {
+				http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+				return
+			}
+			c.setEnabled(enabled)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Enabled bool `json:"enabled"`
+		}{c.Enabled()})
+	})
+}
+
+// fault is the fault chosen for a request.
+type fault struct {
+	latency time.Duration
+	abort   bool
+	code    int
+}
+
+func (c *Chaos) roll() (fault, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if !c.enabled {
+		return fault{}, false
+	}
+
+	var f fault
+	f.latency = c.minLatency
+	if spread := c.maxLatency - c.minLatency; spread > 0 {
+		f.latency += time.Duration(c.rand.Int63n(int64(spread)))
+	}
+	// One roll is split between the outcomes,
+	// so each rate applies to all requests.
+	switch p := c.rand.Float64(); {
+	case p < c.abortRate:
+		f.abort = true
+	case p < c.abortRate+c.errorRate:
+		f.code = c.codes[c.rand.Intn(len(c.codes))]
+	}
+	return f, true
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	serve := func(h http.Handler) (code int, aborted bool) {
+		defer func() {
+			if r := recover(); r != nil {
+				if r != http.ErrAbortHandler {
+					panic(r)
+				}
+				aborted = true
+			}
+		}()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Code, false
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+
+	t.Run("is disabled by default", func(t *testing.T) {
+		c := NewChaos(ChaosErrors(1))
+		if code, _ := serve(c.Middleware(ok)); code != http.StatusOK {
+			t.Fatalf("Expected status %d but got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("injects errors", func(t *testing.T) {
+		c := NewChaos(ChaosEnabled(), ChaosErrors(1, http.StatusTooManyRequests))
+		if code, _ := serve(c.Middleware(ok)); code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status %d but got %d", http.StatusTooManyRequests, code)
+		}
+	})
+
+	t.Run("injects aborts", func(t *testing.T) {
+		c := NewChaos(ChaosEnabled(), ChaosAborts(1))
+		if _, aborted := serve(c.Middleware(ok)); !aborted {
+			t.Fatalf("Expected request to be aborted")
+		}
+	})
+
+	t.Run("applies rates to all requests", func(t *testing.T) {
+		c := NewChaos(ChaosEnabled(), ChaosAborts(0.5), ChaosErrors(0.3))
+		c.rand = rand.New(rand.NewSource(1))
+
+		const n = 10000
+		var aborts, errs int
+		for i := 0; i < n; i++ {
+			f, _ := c.roll()
+			switch {
+			case f.abort:
+				aborts++
+			case f.code != 0:
+				errs++
+			}
+		}
+		if share := float64(aborts) / n; math.Abs(share-0.5) > 0.03 {
+			t.Fatalf("Expected abort share to be about 0.5 but got %v", share)
+		}
+		if share := float64(errs) / n; math.Abs(share-0.3) > 0.03 {
+			t.Fatalf("Expected error share to be about 0.3 but got %v", share)
+		}
+	})
+
+	t.Run("injects latency", func(t *testing.T) {
+		c := NewChaos(ChaosEnabled(), ChaosLatency(time.Millisecond*20, time.Millisecond*30))
+		start := time.Now()
+		serve(c.Middleware(ok))
+		if d := time.Since(start); d < time.Millisecond*20 {
+			t.Fatalf("Expected latency of at least 20ms but got %s", d)
+		}
+	})
+
+	t.Run("toggles via handler", func(t *testing.T) {
+		c := NewChaos()
+		rec := httptest.NewRecorder()
+		c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/chaos?enabled=true", nil))
+
+		if !c.Enabled() {
+			t.Fatalf("Expected chaos to be enabled")
+		}
+		if !strings.Contains(rec.Body.String(), `"enabled":true`) {
+			t.Fatalf("Unexpected body: %s", rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// CertMapper maps a verified client certificate to the principal.
+// It returns false if the certificate does not identify a known principal.
+type CertMapper func(cert *x509.Certificate) (Principal, bool)
+
+// ClientCert returns an authenticator for mutual TLS. It maps the verified
+// client certificate of the connection to the principal with mapper,
+// so service identities go through the same Auth pipeline as tokens:
+//
+//	authn := middleware.AnyOf(middleware.ClientCert(middleware.CertPrincipal), tokens)
+//
+// Requests without a verified client certificate have no credentials.
+// The server must request and verify client certificates, see
+// server.ClientCerts.
+func ClientCert(mapper CertMapper) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) (Principal, error) {
+		if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+			return Principal{}, ErrNoCredentials
+		}
+		p, ok := mapper(req.TLS.VerifiedChains[0][0])
+		if !ok {
+			return Principal{}, errors.New("unknown client certificate")
+		}
+		return p, nil
+	})
+}
+
+// CertPrincipal is a CertMapper that identifies the principal by the first
+// URI SAN of the certificate, e.g. a SPIFFE ID, or by the first DNS SAN
+// if there are no URIs. The claims are "cn" with the subject common name
+// and "ou" with the list of the subject organizational units, so
+// authorizers can allow whole units.
+func CertPrincipal(cert *x509.Certificate) (Principal, bool) {
+	var id string
+	switch {
+	case len(cert.URIs) > 0:
+		id = cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		id = cert.DNSNames[0]
+	default:
+		return Principal{}, false
+	}
+
+	return Principal{
+		ID: id,
+		Claims: serverctx.Claims{
+			"cn": cert.Subject.CommonName,
+			"ou": cert.Subject.OrganizationalUnit,
+		},
+	}, true
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClientCert(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "billing", OrganizationalUnit: []string{"payments"}},
+		URIs:    []*url.URL{spiffe},
+	}
+
+	handler := Auth(ClientCert(CertPrincipal), nil)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p, _ := GetPrincipal(req.Context())
+		w.Header().Set("X-Principal", p.ID)
+	}))
+
+	t.Run("ok", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Header().Get("X-Principal") != "spiffe://example.org/billing" {
+			t.Fatalf("Unexpected response: %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status %d but got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("claims", func(t *testing.T) {
+		p, ok := CertPrincipal(cert)
+		if !ok {
+			t.Fatalf("Expected certificate to be mapped")
+		}
+		if p.Claims["cn"] != "billing" || p.Claims["ou"].([]string)[0] != "payments" {
+			t.Fatalf("Unexpected claims: %v", p.Claims)
+		}
+		if _, ok := CertPrincipal(&x509.Certificate{}); ok {
+			t.Fatalf("Expected certificate without SANs not to be mapped")
+		}
+	})
+}
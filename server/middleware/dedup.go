@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hypnoglow/x/server/idempotency"
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// DedupOption is an option for Dedup.
+type DedupOption func(*dedup)
+
+// DedupMaxBody returns an option that sets the maximum size in bytes
+// of request and response bodies to buffer. Requests with larger bodies
+// are not deduplicated, and duplicates of requests with larger responses
+// get 409 instead of the original response. Default is 1 MiB.
+func DedupMaxBody(n int64) DedupOption {
+	return func(d *dedup) {
+		d.maxBody = n
+	}
+}
+
+// DedupRoutes returns an option that makes Dedup detect identical requests
+// to the routes declared non-idempotent in reg, of any method, instead of
+// POST requests only. Requests to undeclared routes are deduplicated
+// unless their method is idempotent.
+func DedupRoutes(reg *idempotency.Registry) DedupOption {
+	return func(d *dedup) {
+		d.routes = reg
+	}
+}
+
+// Dedup returns a middleware that detects identical POST requests within
+// the window, protecting non-idempotent endpoints from double submits.
+// Requests are identical if they have the same URI, body and key returned
+// by keyFn. If keyFn is nil, the key is the principal ID set by Auth,
+// or the client IP if the request is not authenticated.
+//
+// A duplicate of a completed request gets the original response.
+// A duplicate of a request that is still being handled gets 409 Conflict.
+// Requests that failed with 5xx are forgotten, so the client can retry them.
+// The window starts when the original request completes. Only the headers
+// set by the wrapped handler are replayed, not the ones set by outer
+// middlewares for the original request, like X-Request-ID.
+func Dedup(window time.Duration, keyFn func(*http.Request) string, opts ...DedupOption) func(http.Handler) http.Handler {
+	d := &dedup{
+		window:  window,
+		keyFn:   keyFn,
+		maxBody: 1 << 20,
+		entries: make(map[string]*dedupEntry),
+		now:     time.Now,
+	}
+	if d.keyFn == nil {
+		d.keyFn = defaultDedupKey
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !d.applies(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			hash, ok := d.hash(req)
+			if !ok {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			e, dup := d.acquire(hash)
+			if dup {
+				if e == nil {
+					http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+					return
+				}
+				e.replay(w)
+				return
+			}
+
+			rec := &dedupRecorder{ResponseWriter: w, max: d.maxBody, before: w.Header().Clone()}
+			completed := false
+			defer func() {
+				d.release(hash, rec, completed)
+			}()
+			next.ServeHTTP(rec, req)
+			completed = true
+		})
+	}
+}
+
+func defaultDedupKey(req *http.Request) string {
+	if p, ok := GetPrincipal(req.Context()); ok {
+		return "principal:" + p.ID
+	}
+	if ip := serverctx.RealIP(req.Context()); ip != "" {
+		return "ip:" + ip
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+type dedup struct {
+	window  time.Duration
+	keyFn   func(*http.Request) string
+	maxBody int64
+	routes  *idempotency.Registry
+	now     func() time.Time
+
+	mx      sync.Mutex
+	entries map[string]*dedupEntry
+	expires expiryQueue
+}
+
+// dedupEntry is a request being handled, or a recorded response
+// if completed is true.
+type dedupEntry struct {
+	completed bool
+	expires   time.Time
+	replay    func(w http.ResponseWriter)
+}
+
+// applies reports whether the request should be deduplicated.
+func (d *dedup) applies(req *http.Request) bool {
+	if d.routes != nil {
+		return !d.routes.Idempotent(req)
+	}
+	return req.Method == http.MethodPost
+}
+
+// hash returns the hash identifying the request. It reports false
+// if the body is too large to be buffered.
+func (d *dedup) hash(req *http.Request) (string, bool) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(req.Body, d.maxBody+1))
+		if err != nil || int64(len(body)) > d.maxBody {
+			req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+			return "", false
+		}
+		req.Body = readCloser{bytes.NewReader(body), req.Body}
+	}
+
+	h := sha256.New()
+	io.WriteString(h, req.URL.RequestURI()+"\n")
+	io.WriteString(h, d.keyFn(req)+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// acquire registers the request. If it is a duplicate, acquire reports true
+// and returns the entry to replay, or nil if the original is in progress.
+func (d *dedup) acquire(hash string) (*dedupEntry, bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	d.expires.evict(d.now(), func(h string, expires time.Time) {
+		// The entry may have been forgotten and recorded again since.
+		if e, ok := d.entries[h]; ok && e.completed && e.expires.Equal(expires) {
+			delete(d.entries, h)
+		}
+	})
+
+	if e, ok := d.entries[hash]; ok {
+		if !e.completed {
+			return nil, true
+		}
+		return e, true
+	}
+	d.entries[hash] = &dedupEntry{}
+	return nil, false
+}
+
+// release records the response of the original request, or forgets
+// the request if it failed or the handler panicked.
+func (d *dedup) release(hash string, rec *dedupRecorder, completed bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if !completed || status >= 500 {
+		delete(d.entries, hash)
+		return
+	}
+
+	e := d.entries[hash]
+	e.completed = true
+	e.expires = d.now().Add(d.window)
+	d.expires.push(hash, e.expires)
+	if rec.overflow {
+		e.replay = func(w http.ResponseWriter) {
+			http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+		}
+		return
+	}
+
+	header := rec.written()
+	body := rec.body.Bytes()
+	e.replay = func(w http.ResponseWriter) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		w.Write(body)
+	}
+}
+
+// dedupRecorder records the response while writing it to the client.
+type dedupRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	max      int64
+	overflow bool
+
+	// before is the header set before the handler was called,
+	// header is the one the handler set, captured when it is written.
+	before http.Header
+	header http.Header
+}
+
+func (w *dedupRecorder) WriteHeader(code int) {
+	w.capture()
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *dedupRecorder) Write(p []byte) (int, error) {
+	w.capture()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.overflow {
+		if int64(w.body.Len()+len(p)) > w.max {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(p)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// capture records the headers the handler set, i.e. the ones that were
+// added or changed since the recorder was created.
+func (w *dedupRecorder) capture() {
+	if w.header != nil {
+		return
+	}
+	w.header = make(http.Header)
+	for k, v := range w.Header() {
+		if !slices.Equal(w.before[k], v) {
+			w.header[k] = slices.Clone(v)
+		}
+	}
+}
+
+// written returns the headers the handler set.
+func (w *dedupRecorder) written() http.Header {
+	w.capture()
+	return w.header
+}
+
+// Unwrap returns the original writer, so http.ResponseController
+// can reach Flush, Hijack, etc.
+func (w *dedupRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
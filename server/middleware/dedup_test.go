@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server/idempotency"
+)
+
+func TestDedup(t *testing.T) {
+	newHandler := func(calls *int32, status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			n := atomic.AddInt32(calls, 1)
+			w.Header().Set("X-Call", string(rune('0'+n)))
+			w.WriteHeader(status)
+			w.Write([]byte("created"))
+		})
+	}
+	post := func(h http.Handler, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("replays the original response", func(t *testing.T) {
+		var calls int32
+		h := Dedup(time.Minute, nil)(newHandler(&calls, http.StatusCreated))
+
+		post(h, `{"item":1}`)
+		w := post(h, `{"item":1}`)
+
+		if calls != 1 {
+			t.Fatalf("Expected handler to be called once but got %d", calls)
+		}
+		if w.Code != http.StatusCreated || w.Body.String() != "created" || w.Header().Get("X-Call") != "1" {
+			t.Fatalf("Expected original response but got %d %v %s", w.Code, w.Header(), w.Body)
+		}
+
+		post(h, `{"item":2}`)
+		if calls != 2 {
+			t.Fatalf("Expected different body to reach the handler")
+		}
+	})
+
+	t.Run("replays only the headers of the handler", func(t *testing.T) {
+		var calls int32
+		var id int32
+		h := Dedup(time.Minute, nil)(newHandler(&calls, http.StatusCreated))
+		// An outer middleware sets per-request headers.
+		h = func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				n := atomic.AddInt32(&id, 1)
+				w.Header().Set("X-Request-ID", string(rune('0'+n)))
+				next.ServeHTTP(w, req)
+			})
+		}(h)
+
+		post(h, "pay")
+		w := post(h, "pay")
+
+		if got := w.Header().Get("X-Request-ID"); got != "2" {
+			t.Fatalf("Expected X-Request-ID of the duplicate but got %q", got)
+		}
+		if got := w.Header().Get("X-Call"); got != "1" {
+			t.Fatalf("Expected X-Call of the original response but got %q", got)
+		}
+	})
+
+	t.Run("evicts expired entries", func(t *testing.T) {
+		var calls int32
+		now := time.Now()
+		var d *dedup
+		h := Dedup(time.Minute, nil, func(dd *dedup) {
+			d = dd
+			d.now = func() time.Time { return now }
+		})(newHandler(&calls, http.StatusOK))
+
+		post(h, "a")
+		post(h, "b")
+		now = now.Add(time.Minute * 2)
+		post(h, "c")
+
+		if n := len(d.entries); n != 1 {
+			t.Fatalf("Expected expired entries to be evicted but got %d entries", n)
+		}
+	})
+
+	t.Run("responds 409 while the original is in progress", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		h := Dedup(time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			close(started)
+			<-release
+		}))
+
+		done := make(chan struct{})
+		go func() {
+			post(h, "pay")
+			close(done)
+		}()
+		<-started
+
+		if w := post(h, "pay"); w.Code != http.StatusConflict {
+			t.Fatalf("Expected status %d but got %d", http.StatusConflict, w.Code)
+		}
+		close(release)
+		<-done
+	})
+
+	t.Run("forgets failed requests", func(t *testing.T) {
+		var calls int32
+		h := Dedup(time.Minute, nil)(newHandler(&calls, http.StatusBadGateway))
+
+		post(h, "pay")
+		post(h, "pay")
+		if calls != 2 {
+			t.Fatalf("Expected failed request to be retried but handler was called %d times", calls)
+		}
+	})
+
+	t.Run("forgets requests after the window", func(t *testing.T) {
+		var calls int32
+		now := time.Now()
+		h := Dedup(time.Minute, nil, func(d *dedup) {
+			d.now = func() time.Time { return now }
+		})(newHandler(&calls, http.StatusOK))
+
+		post(h, "pay")
+		now = now.Add(time.Minute * 2)
+		post(h, "pay")
+		if calls != 2 {
+			t.Fatalf("Expected request after the window to reach the handler but it was called %d times", calls)
+		}
+	})
+
+	t.Run("distinguishes keys", func(t *testing.T) {
+		var calls int32
+		h := Dedup(time.Minute, func(req *http.Request) string {
+			return req.Header.Get("X-User")
+		})(newHandler(&calls, http.StatusOK))
+
+		for _, user := range []string{"alice", "bob"} {
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("pay"))
+			req.Header.Set("X-User", user)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		if calls != 2 {
+			t.Fatalf("Expected requests of different users to reach the handler but it was called %d times", calls)
+		}
+	})
+
+	t.Run("ignores other methods", func(t *testing.T) {
+		var calls int32
+		h := Dedup(time.Minute, nil)(newHandler(&calls, http.StatusOK))
+
+		for i := 0; i < 2; i++ {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+		}
+		if calls != 2 {
+			t.Fatalf("Expected GET requests to reach the handler but it was called %d times", calls)
+		}
+	})
+
+	t.Run("follows declared routes", func(t *testing.T) {
+		reg := idempotency.NewRegistry()
+		reg.Declare("POST /search", true)
+		reg.Declare("PATCH /orders/{id}", false)
+
+		var calls int32
+		h := Dedup(time.Minute, nil, DedupRoutes(reg))(newHandler(&calls, http.StatusOK))
+		send := func(method, target string) {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, strings.NewReader("q")))
+		}
+
+		send(http.MethodPost, "/search")
+		send(http.MethodPost, "/search")
+		if calls != 2 {
+			t.Fatalf("Expected idempotent POST requests to reach the handler but it was called %d times", calls)
+		}
+
+		send(http.MethodPatch, "/orders/1")
+		send(http.MethodPatch, "/orders/1")
+		if calls != 3 {
+			t.Fatalf("Expected non-idempotent PATCH to be deduplicated but handler was called %d times", calls)
+		}
+	})
+}
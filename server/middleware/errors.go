@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hypnoglow/x/server/render"
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// Errors that handlers wrap to be responded with the matching status:
+//
+//	return fmt.Errorf("order %s: %w", id, middleware.ErrNotFound)
+var (
+	ErrInvalid      = errors.New("invalid request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+)
+
+// HandlerFunc is a handler that returns an error instead of writing it.
+type HandlerFunc func(w http.ResponseWriter, req *http.Request) error
+
+// ErrorMapper converts the error returned by a handler
+// to the error rendered to the client. If it returns nil,
+// the error is rendered as 500 without exposing its text.
+type ErrorMapper func(err error) *render.Error
+
+// MapError is the default ErrorMapper:
+//
+//   - *render.Error, also wrapped, is used as is;
+//   - ErrInvalid, ErrUnauthorized, ErrForbidden, ErrNotFound and ErrConflict,
+//     also wrapped, map to 400, 401, 403, 404 and 409, with the error text
+//     as the message;
+//   - context.DeadlineExceeded maps to 504;
+//   - other errors map to 500 without exposing their text.
+func MapError(err error) *render.Error {
+	var re *render.Error
+	if errors.As(err, &re) {
+		return re
+	}
+
+	for _, m := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{ErrInvalid, http.StatusBadRequest, "invalid"},
+		{ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+		{ErrForbidden, http.StatusForbidden, "forbidden"},
+		{ErrNotFound, http.StatusNotFound, "not_found"},
+		{ErrConflict, http.StatusConflict, "conflict"},
+	} {
+		if errors.Is(err, m.err) {
+			return &render.Error{Status: m.status, Code: m.code, Message: err.Error(), Err: err}
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &render.Error{Status: http.StatusGatewayTimeout, Err: err}
+	}
+	return &render.Error{Status: http.StatusInternalServerError, Err: err}
+}
+
+// E returns a handler that calls fn and renders the error it returns,
+// if any, mapped with MapError. See ErrorMapper.E.
+func E(fn HandlerFunc) http.Handler {
+	return ErrorMapper(MapError).E(fn)
+}
+
+// E returns a handler that calls fn and renders the error it returns,
+// if any, mapped with m, with render.ProblemFor. The response carries
+// the request ID set by RequestID middleware. If fn has already started
+// the response, the error is not rendered.
+func (m ErrorMapper) E(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		err := fn(rw, req)
+		if err == nil || rw.status != 0 {
+			return
+		}
+
+		re := render.Error{Status: http.StatusInternalServerError, Err: err}
+		if mapped := m(err); mapped != nil {
+			re = *mapped
+		}
+		if re.RequestID == "" {
+			re.RequestID = serverctx.RequestID(req.Context())
+		}
+		render.ProblemFor(w, req, &re)
+	})
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hypnoglow/x/server/render"
+)
+
+func TestE(t *testing.T) {
+	testCases := map[string]struct {
+		err            error
+		expectedStatus int
+		expectedDetail string
+	}{
+		"not found":    {fmt.Errorf("order 42: %w", ErrNotFound), http.StatusNotFound, "order 42: not found"},
+		"invalid":      {fmt.Errorf("amount: %w", ErrInvalid), http.StatusBadRequest, "amount: invalid request"},
+		"unauthorized": {ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+		"render error": {fmt.Errorf("pay: %w", &render.Error{Status: http.StatusPaymentRequired, Message: "Top up"}), http.StatusPaymentRequired, "Top up"},
+		"internal":     {errors.New("dial tcp: connection refused"), http.StatusInternalServerError, "Internal Server Error"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			h := RequestID()(E(func(w http.ResponseWriter, req *http.Request) error {
+				return tc.err
+			}))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+			req.Header.Set(RequestIDHeader, "abc")
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d but got %d", tc.expectedStatus, w.Code)
+			}
+			var body struct {
+				Detail    string `json:"detail"`
+				RequestID string `json:"request_id"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if body.Detail != tc.expectedDetail || body.RequestID != "abc" {
+				t.Fatalf("Unexpected body: %s", w.Body)
+			}
+		})
+	}
+
+	t.Run("does not render after response is started", func(t *testing.T) {
+		h := E(func(w http.ResponseWriter, req *http.Request) error {
+			io.WriteString(w, "partial")
+			return errors.New("broken pipe")
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK || w.Body.String() != "partial" {
+			t.Fatalf("Unexpected response: %d %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("custom mapper", func(t *testing.T) {
+		mapper := ErrorMapper(func(err error) *render.Error {
+			return &render.Error{Status: http.StatusTeapot}
+		})
+		h := mapper.E(func(w http.ResponseWriter, req *http.Request) error {
+			return errors.New("boom")
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusTeapot {
+			t.Fatalf("Expected status %d but got %d", http.StatusTeapot, w.Code)
+		}
+	})
+
+	t.Run("mapper returning nil", func(t *testing.T) {
+		mapper := ErrorMapper(func(err error) *render.Error {
+			return nil
+		})
+		h := mapper.E(func(w http.ResponseWriter, req *http.Request) error {
+			return errors.New("boom")
+		})
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d but got %d", http.StatusInternalServerError, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "boom") {
+			t.Fatalf("Expected error text not to be exposed but got %s", w.Body)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import "time"
+
+// expiryQueue is a FIFO of keys with their expiry times. Keys are ordered
+// by expiry as long as they are pushed with the same delay from now,
+// so expired keys are evicted from the front in amortized constant time
+// instead of scanning all of them on every request.
+type expiryQueue struct {
+	items []expiryItem
+}
+
+type expiryItem struct {
+	key     string
+	expires time.Time
+}
+
+// push adds the key expiring at the time.
+func (q *expiryQueue) push(key string, expires time.Time) {
+	q.items = append(q.items, expiryItem{key: key, expires: expires})
+}
+
+// evict pops the keys expired by now and calls fn for each of them.
+func (q *expiryQueue) evict(now time.Time, fn func(key string, expires time.Time)) {
+	for len(q.items) > 0 && now.After(q.items[0].expires) {
+		fn(q.items[0].key, q.items[0].expires)
+		q.items[0] = expiryItem{}
+		q.items = q.items[1:]
+	}
+}
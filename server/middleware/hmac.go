@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers of signed requests.
+const (
+	SignatureKeyIDHeader     = "X-Signature-Key-ID"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureHeader          = "X-Signature"
+	ContentDigestHeader      = "X-Content-SHA256"
+)
+
+// HMACOption is an option for HMACSignature.
+type HMACOption func(*hmacVerifier)
+
+// HMACWindow returns an option that sets how far the request timestamp
+// may be from the current time. Requests outside the window are rejected,
+// and signatures seen within the window are not accepted again.
+// Default is 5 minutes.
+func HMACWindow(d time.Duration) HMACOption {
+	return func(v *hmacVerifier) {
+		v.window = d
+	}
+}
+
+// HMACMaxBody returns an option that sets the maximum request body size
+// in bytes. Requests with larger bodies are rejected. Default is 1 MiB.
+func HMACMaxBody(n int64) HMACOption {
+	return func(v *hmacVerifier) {
+		v.maxBody = n
+	}
+}
+
+// HMACSignature returns a middleware that verifies requests signed
+// with HMACSign, as usually done by webhook senders.
+//
+// The secretLookup function returns the secret for the key ID
+// from the X-Signature-Key-ID header, or nil if the key is unknown.
+// The middleware checks that X-Content-SHA256 matches the body,
+// that X-Signature-Timestamp is within the window, and that
+// X-Signature is the valid HMAC-SHA256 of the request. A request
+// that fails any check gets 401 and does not reach the handler.
+func HMACSignature(secretLookup func(keyID string) []byte, opts ...HMACOption) func(http.Handler) http.Handler {
+	v := &hmacVerifier{
+		secretLookup: secretLookup,
+		window:       time.Minute * 5,
+		maxBody:      1 << 20,
+		seen:         make(map[string]time.Time),
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if err := v.verify(req); err != nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// HMACSign signs the request for HMACSignature middleware.
+// It reads the request body and replaces it with an equal one.
+func HMACSign(req *http.Request, keyID string, secret []byte) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	digest := sha256.Sum256(body)
+	req.Header.Set(SignatureKeyIDHeader, keyID)
+	req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(ContentDigestHeader, hex.EncodeToString(digest[:]))
+	req.Header.Set(SignatureHeader, hex.EncodeToString(signature(req, secret)))
+	return nil
+}
+
+// signature returns HMAC-SHA256 of the request method, URI and signed headers.
+func signature(req *http.Request, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	io.WriteString(mac, req.Method+"\n")
+	io.WriteString(mac, req.URL.RequestURI()+"\n")
+	io.WriteString(mac, req.Header.Get(SignatureKeyIDHeader)+"\n")
+	io.WriteString(mac, req.Header.Get(SignatureTimestampHeader)+"\n")
+	io.WriteString(mac, req.Header.Get(ContentDigestHeader))
+	return mac.Sum(nil)
+}
+
+type hmacVerifier struct {
+	secretLookup func(keyID string) []byte
+	window       time.Duration
+	maxBody      int64
+	now          func() time.Time
+
+	mx      sync.Mutex
+	seen    map[string]time.Time
+	expires expiryQueue
+}
+
+func (v *hmacVerifier) verify(req *http.Request) error {
+	secret := v.secretLookup(req.Header.Get(SignatureKeyIDHeader))
+	if secret == nil {
+		return errors.New("unknown key")
+	}
+
+	ts, err := strconv.ParseInt(req.Header.Get(SignatureTimestampHeader), 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp")
+	}
+	now := v.now()
+	if d := now.Sub(time.Unix(ts, 0)); d > v.window || d < -v.window {
+		return errors.New("timestamp is out of window")
+	}
+
+	expected, err := hex.DecodeString(req.Header.Get(SignatureHeader))
+	if err != nil || !hmac.Equal(expected, signature(req, secret)) {
+		return errors.New("invalid signature")
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err = ioutil.ReadAll(io.LimitReader(req.Body, v.maxBody+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(body)) > v.maxBody {
+			return errors.New("body is too large")
+		}
+		req.Body = readCloser{bytes.NewReader(body), req.Body}
+	}
+	digest := sha256.Sum256(body)
+	if !hmac.Equal([]byte(hex.EncodeToString(digest[:])), []byte(req.Header.Get(ContentDigestHeader))) {
+		return errors.New("body digest mismatch")
+	}
+
+	// The signature is remembered decoded, since hex decoding ignores case
+	// and the same signature could be replayed in another case.
+	return v.remember(string(expected), now)
+}
+
+// remember rejects signatures that were already seen within the window.
+func (v *hmacVerifier) remember(sig string, now time.Time) error {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+
+	v.expires.evict(now, func(s string, expires time.Time) {
+		if v.seen[s].Equal(expires) {
+			delete(v.seen, s)
+		}
+	})
+	if _, ok := v.seen[sig]; ok {
+		return errors.New("replayed request")
+	}
+	expires := now.Add(v.window * 2)
+	v.seen[sig] = expires
+	v.expires.push(sig, expires)
+	return nil
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSignature(t *testing.T) {
+	secrets := map[string][]byte{"k1": []byte("secret")}
+	handler := HMACSignature(func(keyID string) []byte {
+		return secrets[keyID]
+	}, HMACWindow(time.Minute))(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.Copy(w, req.Body)
+	}))
+
+	signed := func(t *testing.T, keyID string, secret []byte) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/hooks?id=1", strings.NewReader("payload"))
+		if err := HMACSign(req, keyID, secret); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return req
+	}
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("accepts signed request", func(t *testing.T) {
+		rec := serve(signed(t, "k1", secrets["k1"]))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d but got %d", http.StatusOK, rec.Code)
+		}
+		if rec.Body.String() != "payload" {
+			t.Fatalf("Expected body to be %q but got %q", "payload", rec.Body.String())
+		}
+	})
+
+	tests := []struct {
+		name   string
+		tamper func(req *http.Request) *http.Request
+	}{
+		{"unsigned", func(req *http.Request) *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/hooks?id=1", strings.NewReader("payload"))
+		}},
+		{"unknown key", func(req *http.Request) *http.Request {
+			req.Header.Set(SignatureKeyIDHeader, "k2")
+			return req
+		}},
+		{"wrong secret", func(req *http.Request) *http.Request {
+			return signed(t, "k1", []byte("guess"))
+		}},
+		{"tampered body", func(req *http.Request) *http.Request {
+			req.Body = ioutil.NopCloser(strings.NewReader("PAYLOAD"))
+			return req
+		}},
+		{"tampered path", func(req *http.Request) *http.Request {
+			req.URL.RawQuery = "id=2"
+			return req
+		}},
+		{"stale timestamp", func(req *http.Request) *http.Request {
+			req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+			return req
+		}},
+		{"replayed", func(req *http.Request) *http.Request {
+			replay := req.Clone(req.Context())
+			replay.Body = ioutil.NopCloser(strings.NewReader("payload"))
+			serve(req)
+			return replay
+		}},
+		{"replayed in upper case", func(req *http.Request) *http.Request {
+			replay := req.Clone(req.Context())
+			replay.Body = ioutil.NopCloser(strings.NewReader("payload"))
+			replay.Header.Set(SignatureHeader, strings.ToUpper(req.Header.Get(SignatureHeader)))
+			serve(req)
+			return replay
+		}},
+	}
+	for _, tc := range tests {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			rec := serve(tc.tamper(signed(t, "k1", secrets["k1"])))
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("Expected status %d but got %d", http.StatusUnauthorized, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHMACSignature_evictsExpiredSignatures(t *testing.T) {
+	v := &hmacVerifier{window: time.Minute, seen: make(map[string]time.Time)}
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if err := v.remember(strconv.Itoa(i), start); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	if err := v.remember("0", start.Add(time.Minute)); err == nil {
+		t.Fatalf("Expected replay within the window to be rejected")
+	}
+	if err := v.remember("3", start.Add(time.Minute*3)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(v.seen) != 1 || len(v.expires.items) != 1 {
+		t.Fatalf("Expected expired signatures to be evicted but got %d", len(v.seen))
+	}
+}
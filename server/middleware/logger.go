@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Logger returns a middleware that writes a line to log for every request
+// with the method, path, response status and latency:
+//
+//	GET /orders 200 1.234ms
+//
+// If the request has an ID injected by RequestID, it is appended to the line.
+//
+// Lines are encoded into pooled buffers without fmt, so logging does not
+// add allocations on the hot path. Each line is written with one call
+// to log.Write. If log is nil, nothing is logged.
+func Logger(log io.Writer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if log == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(rw, req)
+
+			latency := time.Since(start)
+			bp := logBufPool.Get().(*[]byte)
+			b := append((*bp)[:0], req.Method...)
+			b = append(b, ' ')
+			b = append(b, req.URL.Path...)
+			b = append(b, ' ')
+			b = strconv.AppendInt(b, int64(rw.Status()), 10)
+			b = append(b, ' ')
+			b = appendDuration(b, latency)
+			if id := GetRequestID(req.Context()); id != "" {
+				b = append(b, ' ')
+				b = append(b, id...)
+			}
+			b = append(b, '\n')
+			log.Write(b)
+
+			*bp = b
+			logBufPool.Put(bp)
+		})
+	}
+}
+
+var logBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// appendDuration appends d formatted as time.Duration.String does,
+// without allocating.
+func appendDuration(b []byte, d time.Duration) []byte {
+	var buf [32]byte
+	w := len(buf)
+
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+
+	if u < uint64(time.Second) {
+		// Less than a second: use the smallest unit that fits,
+		// with fractional digits.
+		var prec int
+		w--
+		buf[w] = 's'
+		w--
+		switch {
+		case u == 0:
+			return append(b, "0s"...)
+		case u < uint64(time.Microsecond):
+			prec = 0
+			buf[w] = 'n'
+		case u < uint64(time.Millisecond):
+			prec = 3
+			// U+00B5 'µ' micro sign is 0xC2 0xB5.
+			w--
+			copy(buf[w:], "µ")
+		default:
+			prec = 6
+			buf[w] = 'm'
+		}
+		w, u = fmtFrac(buf[:w], u, prec)
+		w = fmtInt(buf[:w], u)
+	} else {
+		w--
+		buf[w] = 's'
+		w, u = fmtFrac(buf[:w], u, 9)
+		w = fmtInt(buf[:w], u%60)
+		u /= 60
+		if u > 0 {
+			w--
+			buf[w] = 'm'
+			w = fmtInt(buf[:w], u%60)
+			u /= 60
+			if u > 0 {
+				w--
+				buf[w] = 'h'
+				w = fmtInt(buf[:w], u)
+			}
+		}
+	}
+
+	if neg {
+		w--
+		buf[w] = '-'
+	}
+	return append(b, buf[w:]...)
+}
+
+// fmtFrac formats the fraction of v/10**prec, e.g. ".12345", into the tail
+// of buf, omitting trailing zeros. It omits the decimal point when the
+// fraction is 0. It returns the index where the output begins and v/10**prec.
+func fmtFrac(buf []byte, v uint64, prec int) (int, uint64) {
+	w := len(buf)
+	print := false
+	for i := 0; i < prec; i++ {
+		digit := v % 10
+		print = print || digit != 0
+		if print {
+			w--
+			buf[w] = byte(digit) + '0'
+		}
+		v /= 10
+	}
+	if print {
+		w--
+		buf[w] = '.'
+	}
+	return w, v
+}
+
+// fmtInt formats v into the tail of buf.
+// It returns the index where the output begins.
+func fmtInt(buf []byte, v uint64) int {
+	w := len(buf)
+	if v == 0 {
+		w--
+		buf[w] = '0'
+		return w
+	}
+	for v > 0 {
+		w--
+		buf[w] = byte(v%10) + '0'
+		v /= 10
+	}
+	return w
+}
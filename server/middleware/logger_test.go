@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAppendDuration(t *testing.T) {
+	for _, d := range []time.Duration{
+		0, 1, 999, time.Microsecond, 1500, time.Millisecond + 234*time.Microsecond,
+		time.Second, 90 * time.Second, time.Hour + 2*time.Minute + 3*time.Second + 4,
+		-time.Millisecond, math.MaxInt64, math.MinInt64,
+	} {
+		if got := string(appendDuration(nil, d)); got != d.String() {
+			t.Fatalf("Expected %q but got %q", d.String(), got)
+		}
+	}
+}
+
+func BenchmarkLogger(b *testing.B) {
+	handler := Logger(ioutil.Discard)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, req)
+	}
+}
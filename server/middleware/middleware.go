@@ -0,0 +1,9 @@
+// Package middleware provides http.Handler middlewares
+// to be used with the server package.
+//
+// Every middleware has the form func(http.Handler) http.Handler,
+// so it can be passed to server.Use, composed with Chain
+// or applied manually:
+//
+//	handler = middleware.Mirror(target, 5)(handler)
+package middleware
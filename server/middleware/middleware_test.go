@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	handler := Chain(mw("first"), mw("second"))(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls = append(calls, "handler")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if strings.Join(calls, ",") != "first,second,handler" {
+		t.Fatalf("Unexpected calls order: %v", calls)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	t.Run("generates ID", func(t *testing.T) {
+		var id string
+		handler := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id = GetRequestID(req.Context())
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if len(id) != 32 {
+			t.Fatalf("Expected generated ID but got %q", id)
+		}
+		if rec.Header().Get(RequestIDHeader) != id {
+			t.Fatalf("Expected response header to be %q but got %q", id, rec.Header().Get(RequestIDHeader))
+		}
+	})
+
+	t.Run("keeps incoming ID", func(t *testing.T) {
+		var id string
+		handler := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id = GetRequestID(req.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, "abc")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if id != "abc" {
+			t.Fatalf("Expected ID to be %q but got %q", "abc", id)
+		}
+	})
+}
+
+func TestLogger(t *testing.T) {
+	var log bytes.Buffer
+	handler := Chain(RequestID(), Logger(&log))(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !regexp.MustCompile(`^POST /orders 201 \S+ abc\n$`).MatchString(log.String()) {
+		t.Fatalf("Unexpected log line: %q", log.String())
+	}
+
+	t.Run("ok for nil writer", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Logger(nil)(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d but got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("responds with 500 for nil writer", func(t *testing.T) {
+		handler := Recover(nil)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d but got %d", http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("responds with 500", func(t *testing.T) {
+		var log bytes.Buffer
+		handler := Recover(&log)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d but got %d", http.StatusInternalServerError, rec.Code)
+		}
+		if !strings.Contains(log.String(), "panic serving GET /: boom") {
+			t.Fatalf("Unexpected log: %q", log.String())
+		}
+	})
+
+	t.Run("keeps started response", func(t *testing.T) {
+		handler := Recover(io.Discard)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("boom")
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d but got %d", http.StatusAccepted, rec.Code)
+		}
+	})
+}
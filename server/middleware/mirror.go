@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MirrorOption is an option for Mirror.
+type MirrorOption func(*mirror)
+
+// MirrorClient returns an option that sets the client used to send mirrored
+// requests. Default is a client with 5 seconds timeout.
+func MirrorClient(client *http.Client) MirrorOption {
+	return func(m *mirror) {
+		m.client = client
+	}
+}
+
+// MirrorMaxBody returns an option that sets the maximum request body size
+// in bytes to buffer for mirroring. Requests with larger bodies are not
+// mirrored. Default is 1 MiB.
+func MirrorMaxBody(n int64) MirrorOption {
+	return func(m *mirror) {
+		m.maxBody = n
+	}
+}
+
+// MirrorMaxInFlight returns an option that sets the maximum number
+// of mirrored requests in flight. Sampled requests over the limit
+// are not mirrored, so a slow target cannot pile up goroutines.
+// Default is 64, which is also used if n is not positive.
+func MirrorMaxInFlight(n int) MirrorOption {
+	return func(m *mirror) {
+		m.maxInFlight = n
+	}
+}
+
+// MirrorCredentials returns an option that keeps the Authorization,
+// Proxy-Authorization and Cookie headers in mirrored requests.
+// By default they are removed, so credentials are not leaked
+// to the target.
+func MirrorCredentials() MirrorOption {
+	return func(m *mirror) {
+		m.credentials = true
+	}
+}
+
+// Mirror returns a middleware that asynchronously copies a sample of incoming
+// requests to the target backend and discards the responses.
+// The percent defines the share of requests to mirror, from 0 to 100.
+//
+// Mirroring never affects the original request: failures of the target
+// are ignored and the original request body is preserved.
+// Credentials are removed from mirrored requests, see MirrorCredentials.
+func Mirror(target *url.URL, percent int, opts ...MirrorOption) func(http.Handler) http.Handler {
+	m := &mirror{
+		target:      target,
+		percent:     percent,
+		client:      &http.Client{Timeout: time.Second * 5},
+		maxBody:     1 << 20,
+		maxInFlight: 64,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.maxInFlight <= 0 {
+		m.maxInFlight = 64
+	}
+	m.inFlight = make(chan struct{}, m.maxInFlight)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if m.sampled() {
+				m.mirror(req)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+type mirror struct {
+	target      *url.URL
+	percent     int
+	client      *http.Client
+	maxBody     int64
+	maxInFlight int
+	credentials bool
+
+	inFlight chan struct{}
+}
+
+// credentialHeaders are removed from mirrored requests by default.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+func (m *mirror) sampled() bool {
+	return rand.Intn(100) < m.percent
+}
+
+func (m *mirror) mirror(req *http.Request) {
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		return
+	}
+	sent := false
+	defer func() {
+		if !sent {
+			<-m.inFlight
+		}
+	}()
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		buf, err := ioutil.ReadAll(io.LimitReader(req.Body, m.maxBody+1))
+		if err != nil || int64(len(buf)) > m.maxBody {
+			// Give the original handler everything we have read so far.
+			req.Body = readCloser{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+			return
+		}
+		req.Body = readCloser{bytes.NewReader(buf), req.Body}
+		body = buf
+	}
+
+	u := *m.target
+	u.Path = joinPath(m.target.Path, req.URL.Path)
+	u.RawPath = ""
+	u.RawQuery = req.URL.RawQuery
+
+	mreq, err := http.NewRequestWithContext(context.Background(), req.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	mreq.Header = req.Header.Clone()
+	mreq.Host = req.Host
+	if !m.credentials {
+		for _, h := range credentialHeaders {
+			mreq.Header.Del(h)
+		}
+	}
+
+	sent = true
+	go func() {
+		defer func() { <-m.inFlight }()
+		resp, err := m.client.Do(mreq)
+		if err != nil {
+			return
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func joinPath(a, b string) string {
+	switch aslash, bslash := strings.HasSuffix(a, "/"), strings.HasPrefix(b, "/"); {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash && a != "" && b != "":
+		return a + "/" + b
+	}
+	return a + b
+}
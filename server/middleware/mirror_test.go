@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMirror(t *testing.T) {
+	t.Run("mirrors request to target", func(t *testing.T) {
+		mirrored := make(chan string, 1)
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			mirrored <- req.Method + " " + req.URL.RequestURI() + " " + string(body)
+		}))
+		defer target.Close()
+
+		u, _ := url.Parse(target.URL + "/shadow")
+		handler := Mirror(u, 100)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.Copy(w, req.Body)
+		}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader("payload"))
+		handler.ServeHTTP(rec, req)
+
+		if rec.Body.String() != "payload" {
+			t.Fatalf("Expected original body to be %q but got %q", "payload", rec.Body.String())
+		}
+
+		select {
+		case got := <-mirrored:
+			expected := "POST /shadow/orders?id=1 payload"
+			if got != expected {
+				t.Fatalf("Expected mirrored request to be %q but got %q", expected, got)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Expected request to be mirrored")
+		}
+	})
+
+	t.Run("does not mirror bodies over the limit", func(t *testing.T) {
+		mirrored := make(chan struct{}, 1)
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mirrored <- struct{}{}
+		}))
+		defer target.Close()
+
+		u, _ := url.Parse(target.URL)
+		handler := Mirror(u, 100, MirrorMaxBody(3))(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.Copy(w, req.Body)
+		}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+		handler.ServeHTTP(rec, req)
+
+		if rec.Body.String() != "payload" {
+			t.Fatalf("Expected original body to be %q but got %q", "payload", rec.Body.String())
+		}
+
+		select {
+		case <-mirrored:
+			t.Fatalf("Expected request not to be mirrored")
+		case <-time.After(time.Millisecond * 100):
+		}
+	})
+
+	t.Run("removes credentials", func(t *testing.T) {
+		mirrored := make(chan http.Header, 2)
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mirrored <- req.Header
+		}))
+		defer target.Close()
+
+		u, _ := url.Parse(target.URL)
+		for _, keep := range []bool{false, true} {
+			var opts []MirrorOption
+			if keep {
+				opts = append(opts, MirrorCredentials())
+			}
+			handler := Mirror(u, 100, opts...)(http.NotFoundHandler())
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			req.Header.Set("Cookie", "session=secret")
+			req.Header.Set("X-Trace", "abc")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			select {
+			case h := <-mirrored:
+				if has := h.Get("Authorization") != "" && h.Get("Cookie") != ""; has != keep {
+					t.Fatalf("Expected credentials to be kept: %v, but got %v", keep, h)
+				}
+				if h.Get("X-Trace") != "abc" {
+					t.Fatalf("Expected other headers to be mirrored but got %v", h)
+				}
+			case <-time.After(time.Second * 5):
+				t.Fatalf("Expected request to be mirrored")
+			}
+		}
+	})
+
+	t.Run("drops requests over the in-flight limit", func(t *testing.T) {
+		var calls int32
+		release := make(chan struct{})
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+		}))
+		defer target.Close()
+		defer close(release)
+
+		u, _ := url.Parse(target.URL)
+		handler := Mirror(u, 100, MirrorMaxInFlight(1))(http.NotFoundHandler())
+		for i := 0; i < 3; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+
+		deadline := time.Now().Add(time.Second * 5)
+		for atomic.LoadInt32(&calls) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected request to be mirrored")
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		time.Sleep(time.Millisecond * 50)
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Fatalf("Expected 1 request to reach the target but got %d", n)
+		}
+	})
+
+	t.Run("uses default in-flight limit if not positive", func(t *testing.T) {
+		mirrored := make(chan struct{}, 1)
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mirrored <- struct{}{}
+		}))
+		defer target.Close()
+
+		u, _ := url.Parse(target.URL)
+		handler := Mirror(u, 100, MirrorMaxInFlight(0))(http.NotFoundHandler())
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		select {
+		case <-mirrored:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Expected request to be mirrored")
+		}
+	})
+}
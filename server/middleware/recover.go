@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// Recover returns a middleware that recovers from panics in handlers,
+// writes the panic value and the stack trace to log, and responds
+// with 500 Internal Server Error if the response is not yet started.
+//
+// Panics with http.ErrAbortHandler are not recovered, so the server
+// can abort the response as intended. Recovered panics are reported
+// with serverctx.ReportPanic, see server.PanicLimit.
+// If log is nil, panics are recovered without being written.
+func Recover(log io.Writer) func(http.Handler) http.Handler {
+	if log == nil {
+		log = ioutil.Discard
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				fmt.Fprintf(log, "panic serving %s %s: %v\n%s", req.Method, req.URL.Path, r, debug.Stack())
+				serverctx.ReportPanic(req.Context(), r)
+				if rw.status == 0 {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// RequestIDHeader is the header carrying the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID returns a middleware that injects the request ID into the
+// request context and the response headers. Handlers get the ID with
+// serverctx.RequestID. The ID is taken from the
+// X-Request-ID request header, or generated if the header is missing.
+func RequestID() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			ctx := serverctx.WithRequestID(req.Context(), id)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// GetRequestID returns the request ID injected by RequestID middleware,
+// or empty string if there is none. It is the same as serverctx.RequestID.
+func GetRequestID(ctx context.Context) string {
+	return serverctx.RequestID(ctx)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
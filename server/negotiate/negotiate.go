@@ -0,0 +1,156 @@
+// Package negotiate implements HTTP content negotiation
+// with the Accept and Accept-Encoding request headers:
+//
+//	switch negotiate.ContentType(r, "application/json", "text/html") {
+//	case "application/json":
+//	    ...
+//	case "text/html":
+//	    ...
+//	default:
+//	    http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+//	}
+//
+// Quality values, wildcards and their precedence are handled as described
+// in RFC 9110, Section 12.5.
+package negotiate
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Identity is the encoding that means no transformation.
+const Identity = "identity"
+
+// ContentType returns the offer that best matches the Accept header
+// of the request, e.g. "application/json". Offers must be media types
+// without parameters. Among offers of equal quality the earlier one wins.
+//
+// If the request has no Accept header, the first offer is returned.
+// If none of the offers is acceptable, empty string is returned,
+// so the handler may respond with 406 Not Acceptable.
+func ContentType(r *http.Request, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	ranges := parse(r.Header.Values("Accept"))
+	if ranges == nil {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := mediaQuality(ranges, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Encoding returns the offer that best matches the Accept-Encoding header
+// of the request, e.g. "gzip". Among offers of equal quality the earlier
+// one wins.
+//
+// If none of the offers is acceptable, including when the request has
+// no Accept-Encoding header, Identity is returned. If identity is not
+// acceptable either, empty string is returned, so the handler may respond
+// with 406 Not Acceptable.
+func Encoding(r *http.Request, offers ...string) string {
+	ranges := parse(r.Header.Values("Accept-Encoding"))
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q, ok := encodingQuality(ranges, offer); ok && q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	// Identity is acceptable unless explicitly excluded.
+	if q, ok := encodingQuality(ranges, Identity); ok && q == 0 {
+		return ""
+	}
+	return Identity
+}
+
+// mediaQuality returns the quality of the most specific range
+// matching the media type.
+func mediaQuality(ranges []acceptRange, offer string) float64 {
+	typ, sub, _ := strings.Cut(strings.ToLower(offer), "/")
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		rtyp, rsub, _ := strings.Cut(r.value, "/")
+		var s int
+		switch {
+		case rtyp == typ && rsub == sub:
+			s = 2
+		case rtyp == typ && rsub == "*":
+			s = 1
+		case rtyp == "*" && rsub == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// encodingQuality returns the quality of the encoding, and false
+// if no range matches it.
+func encodingQuality(ranges []acceptRange, offer string) (float64, bool) {
+	offer = strings.ToLower(offer)
+
+	q, ok := 0.0, false
+	for _, r := range ranges {
+		switch r.value {
+		case offer:
+			return r.q, true
+		case "*":
+			q, ok = r.q, true
+		}
+	}
+	return q, ok
+}
+
+type acceptRange struct {
+	value string
+	q     float64
+}
+
+// parse parses the values of an Accept-like header, lower-casing the ranges
+// and dropping their parameters other than quality. It returns nil
+// if there are no values.
+func parse(values []string) []acceptRange {
+	var ranges []acceptRange
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			params := strings.Split(part, ";")
+			value := strings.ToLower(strings.TrimSpace(params[0]))
+			if value == "" {
+				continue
+			}
+
+			q := 1.0
+			for _, p := range params[1:] {
+				k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+				if strings.ToLower(strings.TrimSpace(k)) != "q" {
+					continue
+				}
+				f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err != nil || f < 0 || f > 1 {
+					f = 0
+				}
+				q = f
+			}
+			ranges = append(ranges, acceptRange{value: value, q: q})
+		}
+	}
+	return ranges
+}
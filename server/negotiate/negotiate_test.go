@@ -0,0 +1,69 @@
+package negotiate
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContentType(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		offers   []string
+		expected string
+	}{
+		{"", []string{"application/json", "text/html"}, "application/json"},
+		{"text/html", []string{"application/json", "text/html"}, "text/html"},
+		{"text/html;q=0.5, application/json", []string{"text/html", "application/json"}, "application/json"},
+		{"text/*, application/json;q=0.1", []string{"application/json", "text/plain"}, "text/plain"},
+		{"*/*", []string{"application/json", "text/html"}, "application/json"},
+		{"text/*;q=0.9, text/plain;q=0", []string{"text/plain", "text/html"}, "text/html"},
+		{"application/xml", []string{"application/json"}, ""},
+		{"Application/JSON; charset=utf-8", []string{"application/json"}, "application/json"},
+		{"text/html;q=bogus", []string{"text/html"}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.accept, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+
+			if v := ContentType(r, tc.offers...); v != tc.expected {
+				t.Fatalf("Expected %q but got %q", tc.expected, v)
+			}
+		})
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	testCases := []struct {
+		acceptEncoding string
+		offers         []string
+		expected       string
+	}{
+		{"", []string{"gzip"}, Identity},
+		{"gzip, br", []string{"br", "gzip"}, "br"},
+		{"gzip;q=0.5, br;q=0.8", []string{"gzip", "br"}, "br"},
+		{"*", []string{"gzip"}, "gzip"},
+		{"*;q=0.1, gzip;q=0", []string{"gzip", "br"}, "br"},
+		{"deflate", []string{"gzip"}, Identity},
+		{"identity;q=0", []string{"gzip"}, ""},
+		{"*;q=0", []string{"gzip"}, ""},
+		{"*;q=0, identity", []string{"gzip"}, Identity},
+		{"GZIP", []string{"gzip"}, "gzip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.acceptEncoding, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tc.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+
+			if v := Encoding(r, tc.offers...); v != tc.expected {
+				t.Fatalf("Expected %q but got %q", tc.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// PanicLimit returns an option that stops the server when handlers panic
+// more than n times within the window, so the orchestrator restarts the
+// instance instead of letting it limp along with a corrupted state.
+// The stop is the same as on a stop signal: Run and Wait return
+// and the server is gracefully shut down.
+//
+// Both panics recovered by middlewares that report them with
+// serverctx.ReportPanic, like middleware.Recover, and panics that reach
+// the server are counted. Panics with http.ErrAbortHandler are not.
+func PanicLimit(n int, window time.Duration) Option {
+	return func(s *Server) {
+		s.panics = &panicTracker{limit: n, window: window}
+	}
+}
+
+// panicTracker counts panics within the sliding window.
+type panicTracker struct {
+	limit  int
+	window time.Duration
+
+	mx    sync.Mutex
+	times []time.Time
+}
+
+// add records the panic and reports whether the limit is exceeded.
+func (t *panicTracker) add(now time.Time) bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	i := 0
+	for i < len(t.times) && now.Sub(t.times[i]) > t.window {
+		i++
+	}
+	t.times = append(t.times[i:], now)
+	return len(t.times) > t.limit
+}
+
+// trackPanics counts the panics of the handler.
+func (s *Server) trackPanics(next http.Handler) http.Handler {
+	report := func(v interface{}) {
+		if v == http.ErrAbortHandler {
+			return
+		}
+		if s.panics.add(time.Now()) {
+			s.logMessage("Panic limit exceeded: more than %d panics in %s, stopping the server\n", s.panics.limit, s.panics.window)
+			s.Stop()
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if r := recover(); r != nil {
+				report(r)
+				// Let net/http log the panic and abort the response as usual.
+				panic(r)
+			}
+		}()
+
+		ctx := serverctx.WithPanicReporter(req.Context(), report)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
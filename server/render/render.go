@@ -0,0 +1,228 @@
+// Package render writes HTTP responses in a uniform way:
+//
+//	func getOrder(w http.ResponseWriter, r *http.Request) {
+//	    order, err := store.Order(r.Context(), id)
+//	    if errors.Is(err, store.ErrNotFound) {
+//	        render.Problem(w, &render.Error{Status: http.StatusNotFound, Code: "order_not_found"})
+//	        return
+//	    }
+//	    if err != nil {
+//	        render.Problem(w, err)
+//	        return
+//	    }
+//	    render.JSON(w, http.StatusOK, order)
+//	}
+//
+// Errors are rendered with the envelope set by SetEnvelope, which defaults
+// to RFC 9457 problem details, so every service and middleware using this
+// package responds with the same error format. ProblemFor negotiates
+// its content type with the request.
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hypnoglow/x/server/negotiate"
+)
+
+// Error is an error to be rendered to the client.
+type Error struct {
+	// Status is the HTTP status code. Defaults to 500 Internal Server Error.
+	Status int
+
+	// Code is a machine-readable error code, e.g. "order_not_found".
+	Code string
+
+	// Message is a human-readable explanation of the error.
+	// Defaults to the status text.
+	Message string
+
+	// Details are additional data, e.g. invalid fields.
+	Details interface{}
+
+	// RequestID is the ID of the failed request, to correlate
+	// the response with the logs.
+	RequestID string
+
+	// Err is the underlying error. It is never rendered.
+	Err error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	msg := e.message()
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) status() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+func (e *Error) message() string {
+	if e.Message == "" {
+		return http.StatusText(e.status())
+	}
+	return e.Message
+}
+
+// Envelope returns the response body for the error.
+// Status and Message of the error are already defaulted.
+type Envelope func(e *Error) interface{}
+
+// ProblemDetails is the default Envelope. It renders the error
+// as RFC 9457 problem details, with code, details and request ID
+// as extension members.
+func ProblemDetails(e *Error) interface{} {
+	return struct {
+		Type      string      `json:"type"`
+		Title     string      `json:"title"`
+		Status    int         `json:"status"`
+		Detail    string      `json:"detail,omitempty"`
+		Code      string      `json:"code,omitempty"`
+		Details   interface{} `json:"details,omitempty"`
+		RequestID string      `json:"request_id,omitempty"`
+	}{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Message,
+		Code:      e.Code,
+		Details:   e.Details,
+		RequestID: e.RequestID,
+	}
+}
+
+var envelope = struct {
+	mx          sync.RWMutex
+	contentType string
+	fn          Envelope
+}{
+	contentType: "application/problem+json",
+	fn:          ProblemDetails,
+}
+
+// SetEnvelope sets the envelope and the content type of error responses
+// rendered by Problem. It is meant to be called once on startup.
+func SetEnvelope(contentType string, fn Envelope) {
+	envelope.mx.Lock()
+	defer envelope.mx.Unlock()
+
+	envelope.contentType = contentType
+	envelope.fn = fn
+}
+
+// JSON writes the value encoded as JSON with the status code.
+// If the value cannot be encoded, it responds with 500 Internal Server Error
+// and returns the encoding error.
+func JSON(w http.ResponseWriter, code int, v interface{}) error {
+	return write(w, code, "application/json; charset=utf-8", v)
+}
+
+// Problem writes the error with the envelope set by SetEnvelope.
+// If err is not an *Error, nor wraps one, it is rendered as 500 Internal
+// Server Error without exposing its text to the client.
+func Problem(w http.ResponseWriter, err error) error {
+	envelope.mx.RLock()
+	contentType := envelope.contentType
+	envelope.mx.RUnlock()
+
+	return problem(w, contentType, err)
+}
+
+func problem(w http.ResponseWriter, contentType string, err error) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Err: err}
+	}
+
+	envelope.mx.RLock()
+	fn := envelope.fn
+	envelope.mx.RUnlock()
+
+	return write(w, e.status(), contentType, fn(&Error{
+		Status:    e.status(),
+		Code:      e.Code,
+		Message:   e.message(),
+		Details:   e.Details,
+		RequestID: e.RequestID,
+		Err:       e.Err,
+	}))
+}
+
+// ProblemFor is like Problem, but negotiates the content type with the
+// Accept header of the request: clients that accept application/json
+// but not the envelope content type, e.g. application/problem+json,
+// get the same body as application/json. If neither is acceptable,
+// the envelope content type is used, since the error must be reported
+// anyway.
+func ProblemFor(w http.ResponseWriter, r *http.Request, err error) error {
+	envelope.mx.RLock()
+	contentType := envelope.contentType
+	envelope.mx.RUnlock()
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	if negotiate.ContentType(r, strings.TrimSpace(mediaType), "application/json") == "application/json" {
+		contentType = "application/json; charset=utf-8"
+	}
+	return problem(w, contentType, err)
+}
+
+// Stream writes the status code and copies r to the response, flushing
+// after every chunk, so the client receives data as soon as it is read.
+// It returns the number of bytes written.
+func Stream(w http.ResponseWriter, code int, contentType string, r io.Reader) (int64, error) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+
+	rc := http.NewResponseController(w)
+	buf := make([]byte, 32*1024)
+	var written int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			nw, werr := w.Write(buf[:n])
+			written += int64(nw)
+			if werr != nil {
+				return written, werr
+			}
+			if ferr := rc.Flush(); ferr != nil && !errors.Is(ferr, http.ErrNotSupported) {
+				return written, ferr
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+func write(w http.ResponseWriter, code int, contentType string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
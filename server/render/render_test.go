@@ -0,0 +1,130 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if err := JSON(w, http.StatusCreated, map[string]int{"id": 1}); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d but got %d", http.StatusCreated, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+			t.Fatalf("Unexpected content type: %s", ct)
+		}
+		if w.Body.String() != "{\"id\":1}\n" {
+			t.Fatalf("Unexpected body: %s", w.Body)
+		}
+	})
+
+	t.Run("responds 500 on encoding failure", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if err := JSON(w, http.StatusOK, func() {}); err == nil {
+			t.Fatalf("Expected error")
+		}
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d but got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestProblem(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := fmt.Errorf("get order: %w", &Error{Status: http.StatusNotFound, Code: "order_not_found"})
+		Problem(w, err)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("Expected status %d but got %d", http.StatusNotFound, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Fatalf("Unexpected content type: %s", ct)
+		}
+		expected := `{"type":"about:blank","title":"Not Found","status":404,"detail":"Not Found","code":"order_not_found"}` + "\n"
+		if w.Body.String() != expected {
+			t.Fatalf("Expected body %s but got %s", expected, w.Body)
+		}
+	})
+
+	t.Run("hides unknown errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Problem(w, errors.New("dial tcp: connection refused"))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d but got %d", http.StatusInternalServerError, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "refused") {
+			t.Fatalf("Expected error text to be hidden but got %s", w.Body)
+		}
+	})
+
+	t.Run("custom envelope", func(t *testing.T) {
+		SetEnvelope("application/json", func(e *Error) interface{} {
+			return map[string]string{"error": e.Code, "message": e.Message}
+		})
+		defer SetEnvelope("application/problem+json", ProblemDetails)
+
+		w := httptest.NewRecorder()
+		Problem(w, &Error{Status: http.StatusConflict, Code: "conflict", Message: "Order is already paid"})
+
+		expected := `{"error":"conflict","message":"Order is already paid"}` + "\n"
+		if w.Body.String() != expected {
+			t.Fatalf("Expected body %s but got %s", expected, w.Body)
+		}
+	})
+}
+
+func TestProblemFor(t *testing.T) {
+	tests := map[string]string{
+		"":                                "application/problem+json",
+		"application/json":                "application/json; charset=utf-8",
+		"application/json, application/*": "application/problem+json",
+		"application/problem+json;q=0.5, */*;q=0.1": "application/problem+json",
+		"text/html": "application/problem+json",
+	}
+	for accept, expected := range tests {
+		t.Run(accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if accept != "" {
+				r.Header.Set("Accept", accept)
+			}
+			w := httptest.NewRecorder()
+			ProblemFor(w, r, &Error{Status: http.StatusNotFound})
+
+			if ct := w.Header().Get("Content-Type"); ct != expected {
+				t.Fatalf("Expected content type %s but got %s", expected, ct)
+			}
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("Expected status %d but got %d", http.StatusNotFound, w.Code)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	n, err := Stream(w, http.StatusOK, "text/csv", strings.NewReader("a,b\n1,2\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if n != 8 || w.Body.String() != "a,b\n1,2\n" {
+		t.Fatalf("Unexpected body (%d bytes): %s", n, w.Body)
+	}
+	if !w.Flushed {
+		t.Fatalf("Expected response to be flushed")
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Fatalf("Unexpected content type: %s", ct)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ListenerFDEnv is the environment variable that passes the listener
+// file descriptor from a restarting server to its successor.
+const ListenerFDEnv = "SERVER_LISTENER_FD"
+
+// RestartOn returns an option that makes the server restart on the given
+// OS signals, usually SIGHUP or SIGUSR2, without dropping connections.
+//
+// On such a signal the server starts a new process from the current
+// executable with the same arguments and passes it the listener.
+// The new process starts accepting connections on the inherited listener,
+// and the old one stops as if it got a stop signal: it drains in-flight
+// requests and exits. If the new process fails to start, the old one
+// keeps serving.
+//
+// Only the main listener is inherited. Use ReusePort to let the admin
+// and ACME challenge servers of both processes overlap.
+//
+// Restart is not supported on Windows.
+func RestartOn(signals ...os.Signal) Option {
+	return func(s *Server) {
+		s.restartSignals = signals
+	}
+}
+
+// ReusePort returns an option that sets SO_REUSEPORT on the server
+// listeners, so that several processes can listen on the same address.
+// It lets a new version of the app start before the old one is stopped.
+//
+// ReusePort is supported on Linux, macOS and BSD only;
+// elsewhere the server fails to listen.
+func ReusePort() Option {
+	return func(s *Server) {
+		s.reusePort = true
+	}
+}
+
+// ServeOn returns an option that makes the server accept connections
+// on ln instead of listening on its address, e.g. on a listener
+// created by a test or a process supervisor.
+func ServeOn(ln net.Listener) Option {
+	return func(s *Server) {
+		s.preset = ln
+	}
+}
+
+// acquireListener returns the listener given with ServeOn, the one
+// inherited from the parent process, or a new one.
+func (s *Server) acquireListener(addr string) (net.Listener, error) {
+	if s.preset != nil {
+		return s.preset, nil
+	}
+	ln, err := s.inheritedListener()
+	if ln != nil || err != nil {
+		return ln, err
+	}
+	return s.listen(addr)
+}
+
+func (s *Server) listen(addr string) (net.Listener, error) {
+	var lc net.ListenConfig
+	if s.reusePort {
+		lc.Control = reusePortControl
+	}
+	return lc.Listen(context.Background(), s.network, addr)
+}
+
+// inheritedListener returns the listener passed by the parent process,
+// or nil if there is none.
+func (s *Server) inheritedListener() (net.Listener, error) {
+	value, ok := os.LookupEnv(ListenerFDEnv)
+	if !ok {
+		return nil, nil
+	}
+	// Do not pass the listener down to the processes started by the app.
+	os.Unsetenv(ListenerFDEnv)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", ListenerFDEnv, value)
+	}
+
+	f := os.NewFile(uintptr(fd), "listener")
+	defer f.Close()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherit listener: %w", err)
+	}
+
+	s.logMessage("Inherited listener @ %s", ln.Addr())
+	return ln, nil
+}
+
+func (s *Server) isRestartSignal(sig os.Signal) bool {
+	for _, rs := range s.restartSignals {
+		if rs == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// restart starts a new process that inherits the server listener.
+func (s *Server) restart() error {
+	ln := s.Listener()
+	if ln == nil {
+		return errors.New("server is not listening")
+	}
+	filer, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return fmt.Errorf("listener %T cannot be passed to another process", ln)
+	}
+	f, err := filer.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{f}
+	// ExtraFiles[0] becomes fd 3 in the new process.
+	cmd.Env = append(environWithout(ListenerFDEnv), ListenerFDEnv+"=3")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	s.logMessage("Restarted as process %d, draining...", cmd.Process.Pid)
+	// The new process outlives this one; there is no one to wait for it.
+	return cmd.Process.Release()
+}
+
+// Listener returns the listener the server accepts connections on.
+// It returns nil until Ready is closed.
+func (s *Server) Listener() net.Listener {
+	select {
+	case <-s.ready:
+		return s.listener
+	default:
+		return nil
+	}
+}
+
+func environWithout(name string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, name+"=") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// scopedMiddleware is a middleware applied to a part of the routes.
+type scopedMiddleware struct {
+	fn     func(http.Handler) http.Handler
+	prefix string // empty means all routes.
+	name   string // set by Named.
+}
+
+// skipRule excludes a route from the middleware with the name.
+type skipRule struct {
+	pattern string
+	name    string
+}
+
+// UseFor returns an option that adds middlewares applied only to the
+// requests matching pattern. Like in http.ServeMux, a pattern ending with
+// a slash matches all paths under it, e.g. "/api/", and any other pattern
+// matches the exact path, e.g. "/healthz". The middlewares take their
+// place in the same chain as the ones given with Use, in the order
+// the options are given:
+//
+//	srv := server.New(addr, handler,
+//	    server.Use(middleware.RequestID()),
+//	    server.UseFor("/api/", middleware.Auth(authn, authz)),
+//	)
+func UseFor(pattern string, mws ...func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		for _, mw := range mws {
+			s.middlewares = append(s.middlewares, scopedMiddleware{fn: mw, prefix: pattern})
+		}
+	}
+}
+
+// Named returns an option that adds the middleware like Use,
+// under the name, so that Skip can refer to it.
+func Named(name string, mw func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, scopedMiddleware{fn: mw, name: name})
+	}
+}
+
+// Skip returns an option that excludes the requests matching pattern,
+// as in UseFor, from the middlewares added with Named under the names:
+//
+//	srv := server.New(addr, handler,
+//	    server.Named("access-log", middleware.Logger(os.Stderr)),
+//	    server.Skip("/healthz", "access-log"),
+//	)
+func Skip(pattern string, names ...string) Option {
+	return func(s *Server) {
+		for _, name := range names {
+			s.skips = append(s.skips, skipRule{pattern: pattern, name: name})
+		}
+	}
+}
+
+// wrap applies the middleware to the handler, bypassing it
+// for the requests out of its scope.
+func (s *Server) wrap(m scopedMiddleware, next http.Handler) http.Handler {
+	wrapped := m.fn(next)
+
+	var skip []string
+	for _, r := range s.skips {
+		if m.name != "" && r.name == m.name {
+			skip = append(skip, r.pattern)
+		}
+	}
+	if m.prefix == "" && len(skip) == 0 {
+		return wrapped
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if m.prefix != "" && !matchPattern(m.prefix, req.URL.Path) {
+			next.ServeHTTP(w, req)
+			return
+		}
+		for _, pattern := range skip {
+			if matchPattern(pattern, req.URL.Path) {
+				next.ServeHTTP(w, req)
+				return
+			}
+		}
+		wrapped.ServeHTTP(w, req)
+	})
+}
+
+func matchPattern(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(path, pattern)
+	}
+	return path == pattern
+}
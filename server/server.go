@@ -3,11 +3,19 @@
 //
 // Typical usage:
 //  srv := server.New(addr, handler)
+//  if err := srv.Run(ctx); err != nil {
+//      log.Fatal(err)
+//  }
+//
+// The example above stops the server when ctx is cancelled
+// or a SIGINT or SIGTERM is sent to the app.
+//
+// The same can be done step by step:
+//  srv := server.New(addr, handler)
 //  go srv.Start()
 //  srv.Wait()
 //  srv.Shutdown()
 //
-// The example above stops the server only when a SIGINT is sent to the app.
 // If you want to manually stop the server, just call Stop() when you need:
 //  go func() {
 //      time.Sleep(time.Second * 5)
@@ -21,22 +29,81 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
 // Server is a http server with graceful shutdown.
 type Server struct {
-	origin *http.Server
-	log    io.Writer
+	origin  *http.Server
+	log     io.Writer
+	logMx   sync.Mutex
+	network string
+
+	ready      chan struct{}
+	listenAddr net.Addr
+	listener   net.Listener
+	preset     net.Listener
+	binds      []*binding
+	reusePort  bool
+	maxConns   int
+	accept     *acceptListener
+
+	handler     atomic.Value
+	swaps       <-chan http.Handler
+	middlewares []scopedMiddleware
+	skips       []skipRule
+	metrics     MetricsRecorder
+	dev         bool
+
+	gc      gcTuning
+	ballast []byte
+	panics  *panicTracker
+
+	admin       *http.Server
+	adminAddr   string
+	adminRoutes []adminRoute
+	drain       *drainTracker
+
+	lameDuckMx    sync.Mutex
+	lameDuckTimer *time.Timer
+
+	shutdownTimeout time.Duration
+	shutdownHooks   []func(ctx context.Context) error
 
-	stopSignals chan os.Signal
-	onceCloser  sync.Once
+	health          bool
+	livenessChecks  []namedCheck
+	readinessChecks []namedCheck
+	drainDelay      time.Duration
+
+	tls        bool
+	tickets    sessionTickets
+	clientCAs  *x509.CertPool
+	clientAuth tls.ClientAuthType
+	certFile   string
+	keyFile    string
+	challenge  *http.Server
+
+	signals        []os.Signal
+	restartSignals []os.Signal
+	trigger        <-chan struct{}
+	stopSignals    chan os.Signal
+	force          chan os.Signal
+	stopSignal     os.Signal
+	stopMx         sync.Mutex
+	stopped        chan struct{}
+	onceCloser     sync.Once
 }
 
 // Option for server.
@@ -49,81 +116,458 @@ func Log(log io.Writer) Option {
 	}
 }
 
-// New returns a new Server.
-func New(addr string, handler http.Handler, opts ...Option) *Server {
-	stopSignals := make(chan os.Signal, 1)
-	signal.Notify(stopSignals, os.Interrupt)
+// Network returns an option that sets the network the server listens on.
+// It must be one of "tcp", "tcp4" or "tcp6". Default is "tcp".
+func Network(network string) Option {
+	return func(s *Server) {
+		s.network = network
+	}
+}
 
-	s := &Server{
-		origin:      &http.Server{Addr: addr, Handler: handler},
-		stopSignals: stopSignals,
+// Use returns an option that adds middlewares around the server handler.
+// The middlewares are composed at Start; the first one is the outermost.
+// See the middleware subpackage for the ones shipped with this package:
+//
+//	srv := server.New(addr, handler, server.Use(
+//	    middleware.RequestID(),
+//	    middleware.Logger(os.Stderr),
+//	    middleware.Recover(os.Stderr),
+//	))
+func Use(mws ...func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		for _, mw := range mws {
+			s.middlewares = append(s.middlewares, scopedMiddleware{fn: mw})
+		}
 	}
+}
 
-	for _, opt := range opts {
-		opt(s)
+// Admin returns an option that enables the admin server on addr.
+// The admin server runs on a separate listener, so it keeps responding
+// while the main server drains, and serves:
+//
+//	GET  /admin/drain-status - drain progress, see DrainStatus.
+//	     /admin/lame-duck    - lame-duck mode control, see LameDuckHandler.
+//	POST /admin/shutdown     - graceful shutdown, see ShutdownHandler.
+func Admin(addr string) Option {
+	return func(s *Server) {
+		s.adminAddr = addr
 	}
+}
 
-	return s
+// AdminHandle returns an option that adds the handler for the pattern
+// to the admin server, e.g. to toggle fault injection at runtime.
+// It has no effect unless the admin server is enabled with Admin.
+func AdminHandle(pattern string, handler http.Handler) Option {
+	return func(s *Server) {
+		s.adminRoutes = append(s.adminRoutes, adminRoute{pattern: pattern, handler: handler})
+	}
+}
+
+type adminRoute struct {
+	pattern string
+	handler http.Handler
+}
+
+// ShutdownTimeout returns an option that sets the maximum duration
+// of the graceful shutdown, including shutdown hooks. Default is 10 seconds.
+func ShutdownTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
+}
+
+// OnShutdown returns an option that registers a hook to run on Shutdown,
+// after the server has stopped handling requests.
+// Hooks run in registration order and share the shutdown deadline,
+// so they can flush metrics, close DB pools, drain background workers, etc.
+func OnShutdown(hook func(ctx context.Context) error) Option {
+	return func(s *Server) {
+		s.shutdownHooks = append(s.shutdownHooks, hook)
+	}
+}
+
+// Signals returns an option that sets the OS signals that stop the server.
+// Default is SIGINT and SIGTERM. If no signals are given,
+// the server does not react to OS signals.
+func Signals(signals ...os.Signal) Option {
+	return func(s *Server) {
+		s.signals = signals
+	}
+}
+
+// StopOn returns an option that makes the server stop
+// when the trigger channel is closed or receives a value.
+func StopOn(trigger <-chan struct{}) Option {
+	return func(s *Server) {
+		s.trigger = trigger
+	}
+}
+
+// New returns a new Server.
+func New(addr string, handler http.Handler, opts ...Option) *Server {
+	return Wrap(&http.Server{Addr: addr, Handler: handler}, opts...)
 }
 
 // Wrap returns a new Server that wraps http.Server.
 func Wrap(srv *http.Server, opts ...Option) *Server {
-	stopSignals := make(chan os.Signal, 1)
-	signal.Notify(stopSignals, os.Interrupt)
-
 	s := &Server{
-		origin:      srv,
-		stopSignals: stopSignals,
+		origin:          srv,
+		network:         "tcp",
+		drain:           newDrainTracker(),
+		shutdownTimeout: defaultShutdownTimeout,
+		signals:         defaultSignals,
+		stopSignals:     make(chan os.Signal, 1),
+		force:           make(chan os.Signal, 1),
+		stopped:         make(chan struct{}),
+		ready:           make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	if signals := append(append([]os.Signal{}, s.signals...), s.restartSignals...); len(signals) > 0 {
+		signal.Notify(s.stopSignals, signals...)
+	}
+
+	s.tls = s.certFile != "" || s.origin.TLSConfig != nil
+	if s.tls {
+		if s.clientCAs != nil {
+			s.setupClientCerts()
+		}
+		s.setupSessionTickets()
+	}
+
+	if s.dev && s.log == nil {
+		s.log = os.Stderr
+	}
+
+	if s.adminAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/admin/drain-status", s.DrainStatusHandler())
+		mux.Handle("/admin/lame-duck", s.LameDuckHandler())
+		mux.Handle("/admin/shutdown", s.ShutdownHandler())
+		if s.dev {
+			mux.Handle("/debug/pprof/", pprofMux())
+		}
+		for _, r := range s.adminRoutes {
+			mux.Handle(r.pattern, r.handler)
+		}
+		s.admin = &http.Server{Addr: s.adminAddr, Handler: mux}
+	}
+
 	return s
 }
 
+// Run starts the server and blocks until ctx is cancelled, a signal
+// is received or Stop is called, then gracefully shuts the server down.
+// It returns the error that made the server fail to serve, if any,
+// joined with errors occurred during the shutdown.
+//
+// Run is convenient to use with run groups:
+//
+//	g.Go(func() error { return srv.Run(ctx) })
+func (s *Server) Run(ctx context.Context) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Start()
+	}()
+
+	s.waitContext(ctx)
+	shutdownErr := s.Shutdown()
+	return errors.Join(<-errc, shutdownErr)
+}
+
 // Start makes server listen and serve.
 // It blocks until server is stopped.
-func (s *Server) Start() {
+// It returns nil if the server was closed by Shutdown,
+// otherwise it returns the serve error.
+func (s *Server) Start() error {
+	s.origin.Handler = s.buildHandler()
+
+	if s.swaps != nil {
+		go s.watchSwaps()
+	}
+	if s.admin != nil {
+		go s.serveAux("Admin", s.admin)
+	}
+	if s.challenge != nil {
+		go s.serveAux("ACME challenge", s.challenge)
+	}
+	if s.tls {
+		go s.rotateSessionTickets()
+	}
+
+	if s.dev {
+		s.logMessage("%s", s.Config().Pretty())
+	} else {
+		s.logMessage("Server config: %s\n", s.Config())
+	}
+	s.tuneGC()
+	if err := s.listenBinds(); err != nil {
+		s.logMessage("%s", err)
+		s.Stop()
+		return err
+	}
+	s.serveBinds()
 	s.logMessage("Start listening @ %s", s.origin.Addr)
-	err := s.origin.ListenAndServe()
+	s.drain.setState(StateServing)
+	err := s.listenAndServe()
 	if err != http.ErrServerClosed {
-		s.logMessage(err.Error())
+		s.logMessage("%s", err)
 		s.Stop() // just to ensure everything is cleaned.
-		return
+		return err
 	}
 
 	s.logMessage("Server closed.")
+	return nil
+}
+
+// buildHandler wraps the server handler with middlewares
+// and the built-in instrumentation.
+func (s *Server) buildHandler() http.Handler {
+	if s.handler.Load() == nil {
+		handler := s.origin.Handler
+		if handler == nil {
+			handler = http.DefaultServeMux
+		}
+		s.SwapHandler(handler)
+	}
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.currentHandler().ServeHTTP(w, req)
+	})
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.wrap(s.middlewares[i], handler)
+	}
+	handler = s.drain.middleware(handler)
+	handler = s.notifyShutdown(handler)
+	if s.panics != nil {
+		handler = s.trackPanics(handler)
+	}
+	if s.metrics != nil {
+		handler = s.instrument(handler)
+	}
+	if s.dev {
+		handler = s.devMiddleware(handler)
+		if s.admin == nil {
+			handler = pprofMiddleware(handler)
+		}
+	}
+	if s.health {
+		handler = s.healthMiddleware(handler)
+	}
+	return handler
+}
+
+func (s *Server) listenAndServe() error {
+	addr := s.origin.Addr
+	if addr == "" {
+		addr = ":http"
+		if s.isTLS() {
+			addr = ":https"
+		}
+	}
+
+	ln, err := s.acquireListener(addr)
+	if err != nil {
+		return err
+	}
+
+	s.listener = ln
+	s.listenAddr = ln.Addr()
+	s.accept = s.newAcceptListener(ln)
+	close(s.ready)
+
+	if s.isTLS() {
+		return s.origin.ServeTLS(s.accept, s.certFile, s.keyFile)
+	}
+	return s.origin.Serve(s.accept)
+}
+
+// serveAux serves an auxiliary server, like the admin one.
+func (s *Server) serveAux(name string, srv *http.Server) {
+	s.logMessage("Start %s listening @ %s", name, srv.Addr)
+	ln, err := s.listen(srv.Addr)
+	if err != nil {
+		s.logMessage("%s server failed: %s", name, err)
+		return
+	}
+
+	if err := srv.Serve(ln); err != http.ErrServerClosed {
+		s.logMessage("%s server failed: %s", name, err)
+	}
+}
+
+// HTTPServer returns the underlying http.Server.
+// Its Handler is replaced with the middleware stack on Start.
+func (s *Server) HTTPServer() *http.Server {
+	return s.origin
+}
+
+// Ready returns a channel that is closed when the server
+// starts accepting connections.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Addr returns the address the server listens on, which is useful
+// when it was started on port 0. It returns nil until Ready is closed.
+func (s *Server) Addr() net.Addr {
+	select {
+	case <-s.ready:
+		return s.listenAddr
+	default:
+		return nil
+	}
 }
 
-// Wait blocks until SIGINT or SIGTERM is received.
+// Wait blocks until SIGINT or SIGTERM is received,
+// or another stop signal if configured with Signals option.
 // Stop() can be called to unblock manually.
 func (s *Server) Wait() {
-	<-s.stopSignals
+	s.waitContext(context.Background())
 }
 
-// Stop unblocks waiting server, closing its signal channel.
+func (s *Server) waitContext(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+		case <-s.trigger:
+		case sig := <-s.stopSignals:
+			if s.isRestartSignal(sig) {
+				if err := s.restart(); err != nil {
+					s.logMessage("Restart failed, keep serving: %s", err)
+					continue
+				}
+			}
+			s.stopMx.Lock()
+			s.stopSignal = sig
+			s.stopMx.Unlock()
+		case <-s.stopped:
+		}
+		return
+	}
+}
+
+// StopSignal returns the signal that stopped the server,
+// or nil if the server was not stopped by a signal.
+// It lets the caller decide, e.g., between reload on SIGHUP and exit.
+func (s *Server) StopSignal() os.Signal {
+	s.stopMx.Lock()
+	defer s.stopMx.Unlock()
+	return s.stopSignal
+}
+
+// Stop unblocks waiting server and stops listening for OS signals.
 func (s *Server) Stop() {
 	s.onceCloser.Do(func() {
 		signal.Stop(s.stopSignals)
-		close(s.stopSignals)
+		close(s.stopped)
 	})
 }
 
-// Shutdown tries to gracefully shutdown server.
-func (s *Server) Shutdown() {
+// InjectSignal delivers sig to the server as if it was sent by the OS.
+// It is meant for tests that need to simulate SIGINT or SIGTERM
+// without signalling the whole test process.
+// If the server is already stopped, a stop signal forces the shutdown,
+// see ErrForced; other signals are dropped. If a signal is pending,
+// sig is dropped.
+func (s *Server) InjectSignal(sig os.Signal) {
+	select {
+	case <-s.stopped:
+		for _, stop := range s.signals {
+			if stop == sig {
+				select {
+				case s.force <- sig:
+				default:
+				}
+			}
+		}
+		return
+	default:
+	}
+
+	select {
+	case s.stopSignals <- sig:
+	default:
+	}
+}
+
+// Shutdown tries to gracefully shutdown server and then runs shutdown hooks.
+// It returns all errors occurred during the shutdown.
+func (s *Server) Shutdown() error {
+	return s.ShutdownContext(context.Background())
+}
+
+// ShutdownContext is like Shutdown, but the shutdown deadline
+// is the earliest of the ctx deadline and the shutdown timeout.
+// A stop signal received during the shutdown forces it, see ErrForced.
+func (s *Server) ShutdownContext(ctx context.Context) error {
 	s.logMessage("Shutdown server...")
 	s.Stop() // in case shutdown is triggered by a signal from os.
 
-	ctx, cancel := context.WithTimeout(context.Background(), gracefulTimeout)
+	ctx, cancel := context.WithTimeout(ctx, s.drainDelay+s.shutdownTimeout)
 	defer cancel()
+	ctx, force := context.WithCancelCause(ctx)
+	defer force(nil)
+	s.watchForce(ctx, force)
+
+	deadline, _ := ctx.Deadline()
+	s.drain.drain(deadline)
+	stats := ShutdownStats{InFlight: s.drain.status().InFlight}
+	start := time.Now()
+	s.waitDrainDelay(ctx)
 
-	if err := s.origin.Shutdown(ctx); err != nil {
+	bindsErr := make(chan error, 1)
+	go func() {
+		bindsErr <- s.shutdownBinds(ctx)
+	}()
+
+	var errs []error
+	err := s.origin.Shutdown(ctx)
+	if err != nil {
 		s.logMessage("Server graceful shutdown failed: %s\n", err)
+		errs = append(errs, err)
 	} else {
 		s.logMessage("Server gracefully shut down.")
 	}
+	if err := <-bindsErr; err != nil {
+		errs = append(errs, err)
+	}
+	s.drain.setState(StateStopped)
+
+	if s.metrics != nil {
+		stats.Duration = time.Since(start)
+		stats.DeadlineExceeded = errors.Is(err, context.DeadlineExceeded)
+		s.metrics.ObserveShutdown(stats)
+	}
+
+	for _, hook := range s.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			s.logMessage("Shutdown hook failed: %s\n", err)
+			errs = append(errs, err)
+		}
+	}
+
+	if s.challenge != nil {
+		if err := s.challenge.Shutdown(ctx); err != nil {
+			s.logMessage("ACME challenge server graceful shutdown failed: %s\n", err)
+		}
+	}
+
+	// The admin server is shut down last, so drain progress
+	// can be observed until the very end.
+	if s.admin != nil {
+		if err := s.admin.Shutdown(ctx); err != nil {
+			s.logMessage("Admin server graceful shutdown failed: %s\n", err)
+		}
+	}
+
+	if context.Cause(ctx) == ErrForced {
+		errs = append(errs, ErrForced)
+	}
+	return errors.Join(errs...)
 }
 
 func (s *Server) logMessage(format string, args ...interface{}) {
@@ -131,9 +575,26 @@ func (s *Server) logMessage(format string, args ...interface{}) {
 		return
 	}
 
-	fmt.Fprintf(s.log, format, args...)
+	bp := logBufPool.Get().(*[]byte)
+	b := fmt.Appendf((*bp)[:0], format, args...)
+
+	s.logMx.Lock()
+	s.log.Write(b)
+	s.logMx.Unlock()
+
+	*bp = b
+	logBufPool.Put(bp)
+}
+
+var logBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
 }
 
 const (
-	gracefulTimeout = time.Second * 10 // TODO: make configurable
+	defaultShutdownTimeout = time.Second * 10
 )
+
+var defaultSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
@@ -0,0 +1,160 @@
+// Package serverctl provides a client for the admin server endpoints
+// of servers built with package server, so that deploy scripts and
+// operator tools can control them programmatically:
+//
+//	ctl := serverctl.New("http://10.0.0.1:8081")
+//	if _, err := ctl.LameDuck(ctx, 0); err != nil {
+//	    log.Fatal(err)
+//	}
+//	if _, err := ctl.WaitIdle(ctx, time.Second); err != nil {
+//	    log.Fatal(err)
+//	}
+//	if _, err := ctl.Shutdown(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Lame-duck mode doubles as the maintenance mode: the server keeps
+// serving, but reports not ready, so it is taken out of rotation.
+package serverctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+// StatusError is returned when the admin server responds
+// with an unexpected status.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("serverctl: unexpected status %d: %s", e.Code, e.Body)
+}
+
+// Client is a client of the admin server.
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// Option for client.
+type Option func(*Client)
+
+// HTTPClient returns an option that sets the HTTP client used to send
+// requests, e.g. one with TLS configured. Default is a client
+// with a 10 seconds timeout.
+func HTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.client = c
+	}
+}
+
+// New returns a new Client of the admin server at baseURL,
+// e.g. "http://127.0.0.1:8081".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: defaultTimeout},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// DrainStatus returns the drain progress of the server.
+func (c *Client) DrainStatus(ctx context.Context) (server.DrainStatus, error) {
+	return c.do(ctx, http.MethodGet, "/admin/drain-status", nil, http.StatusOK)
+}
+
+// LameDuck puts the server in lame-duck mode for d, or until Resume
+// if d is zero, and returns its drain progress.
+func (c *Client) LameDuck(ctx context.Context, d time.Duration) (server.DrainStatus, error) {
+	var query url.Values
+	if d > 0 {
+		query = url.Values{"duration": {d.String()}}
+	}
+	return c.do(ctx, http.MethodPost, "/admin/lame-duck", query, http.StatusOK)
+}
+
+// Resume takes the server out of lame-duck mode
+// and returns its drain progress.
+func (c *Client) Resume(ctx context.Context) (server.DrainStatus, error) {
+	return c.do(ctx, http.MethodDelete, "/admin/lame-duck", nil, http.StatusOK)
+}
+
+// Shutdown asks the server to shut down gracefully, as a stop signal does,
+// and returns its drain progress at the moment of the request.
+// Use WaitIdle to wait for the in-flight requests.
+func (c *Client) Shutdown(ctx context.Context) (server.DrainStatus, error) {
+	return c.do(ctx, http.MethodPost, "/admin/shutdown", nil, http.StatusAccepted)
+}
+
+// WaitIdle polls the drain progress every interval until the server
+// has no requests in flight, and returns the last status.
+// Together with LameDuck it lets a deploy script wait until
+// the traffic has moved away from the server.
+func (c *Client) WaitIdle(ctx context.Context, interval time.Duration) (server.DrainStatus, error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		st, err := c.DrainStatus(ctx)
+		if err != nil || st.InFlight == 0 {
+			return st, err
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return st, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, code int) (server.DrainStatus, error) {
+	var st server.DrainStatus
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return st, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return st, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != code {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return st, &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return st, fmt.Errorf("serverctl: decode %s %s: %w", method, path, err)
+	}
+	return st, nil
+}
+
+const (
+	defaultTimeout = time.Second * 10
+	maxErrorBody   = 1 << 10
+)
@@ -0,0 +1,105 @@
+package serverctl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/servertest"
+)
+
+func TestClient(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	ts := servertest.StartTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "Just testing!")
+	}))
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/drain-status", ts.DrainStatusHandler())
+	mux.Handle("/admin/lame-duck", ts.LameDuckHandler())
+	mux.Handle("/admin/shutdown", ts.ShutdownHandler())
+	admin := httptest.NewServer(mux)
+	defer admin.Close()
+
+	ctx := context.Background()
+	ctl := New(admin.URL + "/")
+
+	t.Run("ok", func(t *testing.T) {
+		st, err := ctl.DrainStatus(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if st.State != server.StateServing {
+			t.Fatalf("Expected state %q but got %q", server.StateServing, st.State)
+		}
+
+		st, err = ctl.LameDuck(ctx, time.Minute)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if st.State != server.StateLameDuck {
+			t.Fatalf("Expected state %q but got %q", server.StateLameDuck, st.State)
+		}
+
+		st, err = ctl.Resume(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if st.State != server.StateServing {
+			t.Fatalf("Expected state %q but got %q", server.StateServing, st.State)
+		}
+	})
+
+	t.Run("Should wait for in-flight requests", func(t *testing.T) {
+		go http.Get(ts.URL)
+		<-started
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+		defer cancel()
+		if _, err := ctl.WaitIdle(waitCtx, time.Millisecond*10); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected error %v but got %v", context.DeadlineExceeded, err)
+		}
+
+		close(release)
+		st, err := ctl.WaitIdle(ctx, time.Millisecond*10)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if st.InFlight != 0 {
+			t.Fatalf("Expected no requests in flight but got %d", st.InFlight)
+		}
+	})
+
+	t.Run("Should report unexpected status", func(t *testing.T) {
+		_, err := New(admin.URL + "/missing").DrainStatus(ctx)
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound {
+			t.Fatalf("Expected status error 404 but got %v", err)
+		}
+	})
+
+	t.Run("Should stop the server", func(t *testing.T) {
+		stopped := make(chan struct{})
+		go func() {
+			ts.Wait()
+			close(stopped)
+		}()
+
+		if _, err := ctl.Shutdown(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		select {
+		case <-stopped:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Expected server to be stopped")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+// Package serverctx provides typed accessors for the request-scoped values
+// attached to the request context by middlewares, so handlers do not have
+// to deal with context keys:
+//
+//	func handler(w http.ResponseWriter, req *http.Request) {
+//	    log.Printf("request %s from %s", serverctx.RequestID(req.Context()), serverctx.RealIP(req.Context()))
+//	}
+//
+// The With* functions are meant for middlewares and tests.
+package serverctx
+
+import "context"
+
+type key int
+
+const (
+	requestIDKey key = iota
+	realIPKey
+	localeKey
+	claimsKey
+	routePatternKey
+	shutdownKey
+	panicReporterKey
+)
+
+// Claims are the claims of an authenticated principal, e.g. from a JWT.
+type Claims map[string]interface{}
+
+// WithRequestID returns a copy of ctx with the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID, or empty string if there is none.
+func RequestID(ctx context.Context) string {
+	v, _ := ctx.Value(requestIDKey).(string)
+	return v
+}
+
+// WithRealIP returns a copy of ctx with the real client IP,
+// e.g. resolved from X-Forwarded-For behind a proxy.
+func WithRealIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, realIPKey, ip)
+}
+
+// RealIP returns the real client IP, or empty string if there is none.
+func RealIP(ctx context.Context) string {
+	v, _ := ctx.Value(realIPKey).(string)
+	return v
+}
+
+// WithLocale returns a copy of ctx with the negotiated locale, e.g. "en-US".
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+// Locale returns the negotiated locale, or empty string if there is none.
+func Locale(ctx context.Context) string {
+	v, _ := ctx.Value(localeKey).(string)
+	return v
+}
+
+// WithClaims returns a copy of ctx with the auth claims.
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// AuthClaims returns the auth claims, or nil if there are none.
+func AuthClaims(ctx context.Context) Claims {
+	v, _ := ctx.Value(claimsKey).(Claims)
+	return v
+}
+
+// WithRoutePattern returns a copy of ctx with the pattern of the route
+// that matched the request, e.g. "/orders/{id}".
+func WithRoutePattern(ctx context.Context, pattern string) context.Context {
+	return context.WithValue(ctx, routePatternKey, pattern)
+}
+
+// RoutePattern returns the matched route pattern, or empty string
+// if there is none.
+func RoutePattern(ctx context.Context) string {
+	v, _ := ctx.Value(routePatternKey).(string)
+	return v
+}
+
+// WithShutdown returns a copy of ctx with the channel that is closed
+// when the server begins to shut down.
+func WithShutdown(ctx context.Context, ch <-chan struct{}) context.Context {
+	return context.WithValue(ctx, shutdownKey, ch)
+}
+
+// Shutdown returns a channel that is closed when the server begins
+// to shut down, so long-lived handlers like streams can finish early.
+// It returns nil, which blocks forever, if there is none.
+func Shutdown(ctx context.Context) <-chan struct{} {
+	v, _ := ctx.Value(shutdownKey).(<-chan struct{})
+	return v
+}
+
+// WithPanicReporter returns a copy of ctx with the function
+// that handles panics recovered from the request handler.
+func WithPanicReporter(ctx context.Context, report func(v interface{})) context.Context {
+	return context.WithValue(ctx, panicReporterKey, report)
+}
+
+// ReportPanic passes the panic value recovered by a middleware to the
+// server, which may count it to detect a broken instance.
+// It is a no-op if there is no reporter in ctx.
+func ReportPanic(ctx context.Context, v interface{}) {
+	if report, ok := ctx.Value(panicReporterKey).(func(v interface{})); ok {
+		report(v)
+	}
+}
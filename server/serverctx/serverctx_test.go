@@ -0,0 +1,59 @@
+package serverctx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAccessors(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		ctx := context.Background()
+		ctx = WithRequestID(ctx, "abc")
+		ctx = WithRealIP(ctx, "10.0.0.1")
+		ctx = WithLocale(ctx, "en-US")
+		ctx = WithClaims(ctx, Claims{"sub": "user"})
+		ctx = WithRoutePattern(ctx, "/orders/{id}")
+		shutdown := make(chan struct{})
+		ctx = WithShutdown(ctx, shutdown)
+
+		if v := RequestID(ctx); v != "abc" {
+			t.Fatalf("Expected request ID to be %q but got %q", "abc", v)
+		}
+		if v := RealIP(ctx); v != "10.0.0.1" {
+			t.Fatalf("Expected real IP to be %q but got %q", "10.0.0.1", v)
+		}
+		if v := Locale(ctx); v != "en-US" {
+			t.Fatalf("Expected locale to be %q but got %q", "en-US", v)
+		}
+		if v := AuthClaims(ctx); v["sub"] != "user" {
+			t.Fatalf("Expected claims to contain sub but got %v", v)
+		}
+		if v := RoutePattern(ctx); v != "/orders/{id}" {
+			t.Fatalf("Expected route pattern to be %q but got %q", "/orders/{id}", v)
+		}
+		if v := Shutdown(ctx); v != shutdown {
+			t.Fatalf("Expected shutdown channel to be %v but got %v", shutdown, v)
+		}
+
+		var reported interface{}
+		ReportPanic(WithPanicReporter(ctx, func(v interface{}) { reported = v }), "boom")
+		if reported != "boom" {
+			t.Fatalf("Expected panic %q to be reported but got %v", "boom", reported)
+		}
+	})
+
+	t.Run("ok for empty context", func(t *testing.T) {
+		ctx := context.Background()
+
+		if RequestID(ctx) != "" || RealIP(ctx) != "" || Locale(ctx) != "" || RoutePattern(ctx) != "" {
+			t.Fatalf("Expected empty values")
+		}
+		if AuthClaims(ctx) != nil {
+			t.Fatalf("Expected nil claims")
+		}
+		if Shutdown(ctx) != nil {
+			t.Fatalf("Expected nil shutdown channel")
+		}
+		ReportPanic(ctx, "boom")
+	})
+}
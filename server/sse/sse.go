@@ -0,0 +1,223 @@
+// Package sse serves Server-Sent Events:
+//
+//	http.Handle("/events", sse.Handler(func(ctx context.Context, w *sse.Writer) {
+//	    for {
+//	        select {
+//	        case <-ctx.Done():
+//	            return
+//	        case price := <-prices:
+//	            w.Send(sse.Event{Event: "price", Data: price})
+//	        }
+//	    }
+//	}))
+//
+// The handler context is cancelled when the client disconnects or when
+// the server begins to shut down; in the latter case context.Cause
+// returns ErrShutdown, so the handler may tell the client to reconnect
+// to another instance before returning.
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// ErrShutdown is the cause of the handler context cancellation
+// when the server begins to shut down.
+var ErrShutdown = errors.New("server is shutting down")
+
+// ErrClosed is returned by Writer methods called after the stream ended.
+var ErrClosed = errors.New("stream is closed")
+
+// Event is a server-sent event.
+type Event struct {
+	// ID sets the last event ID the client sends on reconnection.
+	ID string
+
+	// Event is the event type. Empty means "message".
+	Event string
+
+	// Data is the event payload. Multi-line data is sent as is.
+	Data string
+
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// Option is an option for Handler.
+type Option func(*handler)
+
+// Heartbeat returns an option that sets the interval of comment lines
+// sent to keep the connection open through proxies and to detect
+// disconnected clients. Zero disables heartbeats. Default is 15 seconds.
+func Heartbeat(d time.Duration) Option {
+	return func(h *handler) {
+		h.heartbeat = d
+	}
+}
+
+// Handler returns a handler that starts an event stream and calls fn
+// to fill it. The stream ends when fn returns.
+func Handler(fn func(ctx context.Context, w *Writer), opts ...Option) http.Handler {
+	h := &handler{
+		fn:        fn,
+		heartbeat: time.Second * 15,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type handler struct {
+	fn        func(ctx context.Context, w *Writer)
+	heartbeat time.Duration
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	defer cancel(nil)
+
+	if !canFlush(rw) {
+		http.Error(rw, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w := &Writer{w: rw, rc: http.NewResponseController(rw), cancel: cancel}
+
+	header := rw.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	if err := w.flush(); err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+		w.close()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var tick <-chan time.Time
+		if h.heartbeat > 0 {
+			ticker := time.NewTicker(h.heartbeat)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-serverctx.Shutdown(req.Context()):
+				cancel(ErrShutdown)
+				return
+			case <-tick:
+				w.Comment("")
+			}
+		}
+	}()
+
+	h.fn(ctx, w)
+}
+
+// Writer writes events to the stream. It is safe for concurrent use.
+// A failed write means the client is gone: it cancels the handler context.
+type Writer struct {
+	mx     sync.Mutex
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	cancel context.CancelCauseFunc
+	closed bool
+}
+
+// Send sends the event to the client.
+func (w *Writer) Send(e Event) error {
+	var b strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", oneLine(e.ID))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", oneLine(e.Event))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	// CRLF and CR end lines in the stream too, so they are split alike.
+	data := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(e.Data)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	return w.write(b.String())
+}
+
+// Comment sends a comment line, which clients ignore.
+func (w *Writer) Comment(text string) error {
+	return w.write(": " + oneLine(text) + "\n\n")
+}
+
+func (w *Writer) write(s string) error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	if _, err := io.WriteString(w.w, s); err != nil {
+		w.cancel(err)
+		return err
+	}
+	if err := w.rc.Flush(); err != nil {
+		w.cancel(err)
+		return err
+	}
+	return nil
+}
+
+func (w *Writer) close() {
+	w.mx.Lock()
+	w.closed = true
+	w.mx.Unlock()
+}
+
+func (w *Writer) flush() error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	return w.rc.Flush()
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+}
+
+// canFlush reports whether rw or any writer it wraps can flush.
+func canFlush(rw http.ResponseWriter) bool {
+	for {
+		switch w := rw.(type) {
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			rw = w.Unwrap()
+		default:
+			return false
+		}
+	}
+}
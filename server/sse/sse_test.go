@@ -0,0 +1,94 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+func TestHandler(t *testing.T) {
+	t.Run("streams events", func(t *testing.T) {
+		srv := httptest.NewServer(Handler(func(ctx context.Context, w *Writer) {
+			w.Send(Event{ID: "1", Event: "greeting", Data: "hello\nworld"})
+			w.Send(Event{Data: "bye", Retry: time.Second})
+			w.Send(Event{Data: "one\r\ntwo\rthree"})
+		}, Heartbeat(0)))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer resp.Body.Close()
+
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("Expected content type %q but got %q", "text/event-stream", ct)
+		}
+
+		var b strings.Builder
+		bufio.NewReader(resp.Body).WriteTo(&b)
+		expected := "id: 1\nevent: greeting\ndata: hello\ndata: world\n\nretry: 1000\ndata: bye\n\n" +
+			"data: one\ndata: two\ndata: three\n\n"
+		if b.String() != expected {
+			t.Fatalf("Expected stream %q but got %q", expected, b.String())
+		}
+	})
+
+	t.Run("sends heartbeats", func(t *testing.T) {
+		srv := httptest.NewServer(Handler(func(ctx context.Context, w *Writer) {
+			<-ctx.Done()
+		}, Heartbeat(time.Millisecond*10)))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer resp.Body.Close()
+
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if line != ": \n" {
+			t.Fatalf("Expected heartbeat comment but got %q", line)
+		}
+	})
+
+	t.Run("stops on shutdown", func(t *testing.T) {
+		shutdown := make(chan struct{})
+		cause := make(chan error, 1)
+		handler := Handler(func(ctx context.Context, w *Writer) {
+			<-ctx.Done()
+			cause <- context.Cause(ctx)
+		})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var ch <-chan struct{} = shutdown
+			handler.ServeHTTP(w, req.WithContext(serverctx.WithShutdown(req.Context(), ch)))
+		}))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer resp.Body.Close()
+
+		close(shutdown)
+		select {
+		case err := <-cause:
+			if !errors.Is(err, ErrShutdown) {
+				t.Fatalf("Expected cause %v but got %v", ErrShutdown, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected handler to stop")
+		}
+	})
+}
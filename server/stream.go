@@ -0,0 +1,61 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// EarlyHints sends a 103 Early Hints informational response with the links,
+// so the client can start preloading resources while the final response
+// is being prepared:
+//
+//	server.EarlyHints(w, "</style.css>; rel=preload; as=style")
+//
+// The links are also kept in the headers of the final response.
+func EarlyHints(w http.ResponseWriter, links ...string) {
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
+
+// Flush sends any buffered response data to the client.
+//
+// Flush looks through response writers wrapped by middlewares as long as
+// they implement Unwrap() http.ResponseWriter, as all middlewares in this
+// module do. It returns an error matching http.ErrNotSupported if the
+// underlying writer cannot flush.
+func Flush(w http.ResponseWriter) error {
+	return http.NewResponseController(w).Flush()
+}
+
+// FlushWriter returns a writer that flushes the response after every write.
+// It is useful for streaming with io.Copy or similar functions.
+func FlushWriter(w http.ResponseWriter) io.Writer {
+	return flushWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+type flushWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, fw.rc.Flush()
+}
+
+// notifyShutdown makes serverctx.Shutdown of the request context fire
+// when the server begins to shut down, so streaming handlers can finish
+// before the shutdown deadline instead of being cut off.
+func (s *Server) notifyShutdown(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := serverctx.WithShutdown(req.Context(), s.stopped)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
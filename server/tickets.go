@@ -0,0 +1,171 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionTicketKeys are keys that encrypt TLS session tickets.
+// The first key encrypts new tickets, all of them decrypt tickets.
+//
+// It implements encoding.TextUnmarshaler, so the keys shared by replicas
+// can be loaded from the environment with the env package as
+// comma-separated base64 strings of 32 bytes each:
+//
+//	var cfg struct {
+//	    TicketKeys server.SessionTicketKeys `env:"TLS_TICKET_KEYS"`
+//	}
+type SessionTicketKeys [][32]byte
+
+// UnmarshalText parses comma-separated base64 keys of 32 bytes each.
+func (k *SessionTicketKeys) UnmarshalText(text []byte) error {
+	var keys SessionTicketKeys
+	for _, s := range strings.Split(string(text), ",") {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("invalid session ticket key: %w", err)
+		}
+		if len(b) != 32 {
+			return fmt.Errorf("session ticket key must be 32 bytes, got %d", len(b))
+		}
+		var key [32]byte
+		copy(key[:], b)
+		keys = append(keys, key)
+	}
+	*k = keys
+	return nil
+}
+
+// SessionTicketRotation returns an option that makes the server rotate
+// TLS session ticket keys every interval: a new random key encrypts
+// new tickets, and the previous one still decrypts tickets for another
+// interval. Frequent rotation limits how much traffic a leaked key exposes.
+// It has no effect if keys are shared with SessionTickets
+// or SetSessionTicketKeys.
+//
+// By default, keys are rotated by crypto/tls, daily.
+func SessionTicketRotation(interval time.Duration) Option {
+	return func(s *Server) {
+		s.tickets.interval = interval
+	}
+}
+
+// SessionTickets returns an option that sets TLS session ticket keys
+// shared by all replicas of the app, so a client can resume its session
+// on any of them. Keys must be rotated by the operator,
+// with SetSessionTicketKeys.
+func SessionTickets(keys SessionTicketKeys) Option {
+	return func(s *Server) {
+		s.tickets.setKeys(keys)
+		s.tickets.shared = true
+	}
+}
+
+// SetSessionTicketKeys replaces TLS session ticket keys, e.g. when new
+// shared keys are rolled out. It stops the rotation enabled with
+// SessionTicketRotation. It may be called while the server is running.
+func (s *Server) SetSessionTicketKeys(keys SessionTicketKeys) {
+	s.tickets.mx.Lock()
+	s.tickets.shared = true
+	s.tickets.mx.Unlock()
+	s.tickets.setKeys(keys)
+}
+
+// sessionTickets encrypts session tickets with the keys managed
+// by the server instead of the ones of the served tls.Config,
+// which is cloned by http.Server and cannot be updated on the fly.
+type sessionTickets struct {
+	// cfg holds the keys; it is never used for handshakes.
+	cfg      tls.Config
+	interval time.Duration
+
+	mx     sync.Mutex
+	shared bool
+	keys   [][32]byte
+}
+
+func (t *sessionTickets) setKeys(keys [][32]byte) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.keys = keys
+	t.cfg.SetSessionTicketKeys(keys)
+}
+
+// rotate prepends a new random key, keeping the previous one.
+// It reports false if the keys are shared.
+func (t *sessionTickets) rotate() (bool, error) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.shared {
+		return false, nil
+	}
+
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return true, err
+	}
+	keys := [][32]byte{key}
+	if len(t.keys) > 0 {
+		keys = append(keys, t.keys[0])
+	}
+	t.keys = keys
+	t.cfg.SetSessionTicketKeys(keys)
+	return true, nil
+}
+
+// setupSessionTickets makes the served tls.Config encrypt session tickets
+// with the managed keys. The config is cloned, so the one passed
+// by the caller is not modified.
+func (s *Server) setupSessionTickets() {
+	cfg := s.origin.TLSConfig
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		if cfg.SessionTicketsDisabled || cfg.WrapSession != nil || cfg.UnwrapSession != nil {
+			return
+		}
+		cfg = cfg.Clone()
+	}
+
+	cfg.WrapSession = func(cs tls.ConnectionState, ss *tls.SessionState) ([]byte, error) {
+		return s.tickets.cfg.EncryptTicket(cs, ss)
+	}
+	cfg.UnwrapSession = func(identity []byte, cs tls.ConnectionState) (*tls.SessionState, error) {
+		return s.tickets.cfg.DecryptTicket(identity, cs)
+	}
+	s.origin.TLSConfig = cfg
+}
+
+// rotateSessionTickets rotates session ticket keys until the server
+// is stopped or the keys become shared.
+func (s *Server) rotateSessionTickets() {
+	if s.tickets.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.tickets.interval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := s.tickets.rotate()
+		if !ok {
+			return
+		}
+		if err != nil {
+			s.logMessage("Session ticket key rotation failed: %s\n", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.stopped:
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+)
+
+// CertManager manages TLS certificates automatically.
+// It is implemented by *autocert.Manager from golang.org/x/crypto/acme/autocert.
+type CertManager interface {
+	// TLSConfig returns a TLS configuration that obtains certificates
+	// on demand.
+	TLSConfig() *tls.Config
+
+	// HTTPHandler returns a handler that serves HTTP-01 challenges
+	// and passes other requests to fallback.
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// TLS returns an option that makes server serve HTTPS
+// using the certificate and key files.
+//
+// When serving HTTPS, HTTP/2 is enabled unless the wrapped http.Server
+// has TLSNextProto set. On Shutdown, HTTP/2 connections are sent a GOAWAY
+// frame right away, so clients stop opening new streams on them while
+// the in-flight streams drain.
+func TLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+// TLSConfig returns an option that makes server serve HTTPS
+// using the TLS configuration. The configuration must contain
+// certificates or GetCertificate callback.
+func TLSConfig(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.origin.TLSConfig = cfg
+	}
+}
+
+// AutoCert returns an option that makes server serve HTTPS using
+// certificates managed by m, e.g. obtained from Let's Encrypt:
+//
+//	m := &autocert.Manager{
+//	    Prompt:     autocert.AcceptTOS,
+//	    HostPolicy: autocert.HostWhitelist("example.com"),
+//	    Cache:      autocert.DirCache("certs"),
+//	}
+//	srv := server.New(":https", handler, server.AutoCert(m, ":http"))
+//
+// The server also listens on challengeAddr to serve HTTP-01 challenges
+// and to redirect other plain HTTP requests to HTTPS.
+func AutoCert(m CertManager, challengeAddr string) Option {
+	return func(s *Server) {
+		s.origin.TLSConfig = m.TLSConfig()
+		s.challenge = &http.Server{
+			Addr:    challengeAddr,
+			Handler: m.HTTPHandler(nil),
+		}
+	}
+}
+
+// ClientCerts returns an option that makes the server request client
+// certificates and verify them against roots, for mutual TLS.
+// If required is true, connections without a valid certificate are
+// refused; otherwise the certificate is optional, but verified if given.
+// Map certificates to principals with middleware.ClientCert.
+// It has no effect unless the server serves HTTPS.
+func ClientCerts(roots *x509.CertPool, required bool) Option {
+	return func(s *Server) {
+		s.clientCAs = roots
+		s.clientAuth = tls.VerifyClientCertIfGiven
+		if required {
+			s.clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+}
+
+// setupClientCerts sets client certificate verification
+// on a copy of the TLS config.
+func (s *Server) setupClientCerts() {
+	cfg := s.origin.TLSConfig
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+	cfg.ClientCAs = s.clientCAs
+	cfg.ClientAuth = s.clientAuth
+	s.origin.TLSConfig = cfg
+}
+
+// isTLS reports whether the server serves HTTPS.
+// It must not look at s.origin.TLSConfig directly, because http.Server
+// sets it on Serve when configuring HTTP/2.
+func (s *Server) isTLS() bool {
+	return s.tls
+}
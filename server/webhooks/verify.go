@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Verifier verifies that a webhook was sent by the expected sender.
+type Verifier interface {
+	// Verify returns an error if the request is not authentic.
+	Verify(req *http.Request, body []byte) error
+}
+
+// VerifierFunc is a function that implements Verifier.
+type VerifierFunc func(req *http.Request, body []byte) error
+
+// Verify calls f(req, body).
+func (f VerifierFunc) Verify(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+// ErrInvalidSignature is returned by verifiers if the signature is missing
+// or does not match.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// ErrExpired is returned by verifiers if the signed timestamp is too old.
+var ErrExpired = errors.New("timestamp is out of tolerance")
+
+// GitHub returns a verifier for the GitHub X-Hub-Signature-256 header.
+func GitHub(secret []byte) Verifier {
+	return VerifierFunc(func(req *http.Request, body []byte) error {
+		sig := strings.TrimPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=")
+		if !validMAC(secret, sig, body) {
+			return ErrInvalidSignature
+		}
+		return nil
+	})
+}
+
+// Stripe returns a verifier for the Stripe-Signature header.
+// The signed timestamp must be within tolerance of the current time;
+// Stripe recommends 5 minutes.
+func Stripe(secret []byte, tolerance time.Duration) Verifier {
+	return VerifierFunc(func(req *http.Request, body []byte) error {
+		var ts string
+		var sigs []string
+		for _, part := range strings.Split(req.Header.Get("Stripe-Signature"), ",") {
+			kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "t":
+				ts = kv[1]
+			case "v1":
+				sigs = append(sigs, kv[1])
+			}
+		}
+
+		if err := checkTimestamp(ts, tolerance); err != nil {
+			return err
+		}
+		payload := append([]byte(ts+"."), body...)
+		for _, sig := range sigs {
+			if validMAC(secret, sig, payload) {
+				return nil
+			}
+		}
+		return ErrInvalidSignature
+	})
+}
+
+// Slack returns a verifier for the Slack X-Slack-Signature header.
+// The signed timestamp must be within tolerance of the current time;
+// Slack recommends 5 minutes.
+func Slack(secret []byte, tolerance time.Duration) Verifier {
+	return VerifierFunc(func(req *http.Request, body []byte) error {
+		ts := req.Header.Get("X-Slack-Request-Timestamp")
+		if err := checkTimestamp(ts, tolerance); err != nil {
+			return err
+		}
+		sig := strings.TrimPrefix(req.Header.Get("X-Slack-Signature"), "v0=")
+		if !validMAC(secret, sig, append([]byte("v0:"+ts+":"), body...)) {
+			return ErrInvalidSignature
+		}
+		return nil
+	})
+}
+
+// validMAC reports whether sig is the hex-encoded HMAC-SHA256 of payload.
+func validMAC(secret []byte, sig string, payload []byte) bool {
+	given, err := hex.DecodeString(sig)
+	if err != nil || len(given) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hmac.Equal(given, mac.Sum(nil))
+}
+
+func checkTimestamp(ts string, tolerance time.Duration) error {
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return ErrExpired
+	}
+	return nil
+}
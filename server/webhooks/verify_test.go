@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifiers(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"id":1}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+		header   http.Header
+		err      error
+	}{
+		{
+			name:     "github",
+			verifier: GitHub(secret),
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=" + sign(secret, string(body))}},
+		},
+		{
+			name:     "github invalid",
+			verifier: GitHub(secret),
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=" + sign([]byte("guess"), string(body))}},
+			err:      ErrInvalidSignature,
+		},
+		{
+			name:     "github missing",
+			verifier: GitHub(secret),
+			header:   http.Header{},
+			err:      ErrInvalidSignature,
+		},
+		{
+			name:     "stripe",
+			verifier: Stripe(secret, time.Minute*5),
+			header:   http.Header{"Stripe-Signature": {"t=" + now + ",v1=00ff,v1=" + sign(secret, now+"."+string(body))}},
+		},
+		{
+			name:     "stripe expired",
+			verifier: Stripe(secret, time.Minute*5),
+			header:   http.Header{"Stripe-Signature": {"t=" + old + ",v1=" + sign(secret, old+"."+string(body))}},
+			err:      ErrExpired,
+		},
+		{
+			name:     "slack",
+			verifier: Slack(secret, time.Minute*5),
+			header: http.Header{
+				"X-Slack-Request-Timestamp": {now},
+				"X-Slack-Signature":         {"v0=" + sign(secret, "v0:"+now+":"+string(body))},
+			},
+		},
+		{
+			name:     "slack tampered timestamp",
+			verifier: Slack(secret, time.Minute*5),
+			header: http.Header{
+				"X-Slack-Request-Timestamp": {now},
+				"X-Slack-Signature":         {"v0=" + sign(secret, "v0:"+old+":"+string(body))},
+			},
+			err: ErrInvalidSignature,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header = tc.header
+
+			err := tc.verifier.Verify(req, body)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected error %v but got %v", tc.err, err)
+			}
+		})
+	}
+}
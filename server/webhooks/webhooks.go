@@ -0,0 +1,143 @@
+// Package webhooks helps to build webhook receivers on top of the server
+// package. A receiver verifies the sender signature, hands the event off
+// to asynchronous processing and responds immediately:
+//
+//	handler := webhooks.Handler(webhooks.GitHub(secret), func(ctx context.Context, e webhooks.Event) error {
+//	    return queue.Enqueue(ctx, e.Body)
+//	})
+//
+// Senders retry deliveries that fail or time out, so the handoff should
+// be fast and the processing should be idempotent; Event.ID and
+// Event.Retry help with the latter.
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// Event is a verified webhook delivery.
+type Event struct {
+	// ID is the delivery ID if the sender provides one, e.g. X-GitHub-Delivery.
+	ID string
+
+	// Retry is the retry number if the sender reports it, e.g. X-Slack-Retry-Num.
+	// It is zero for the first delivery or if the sender does not report it.
+	Retry int
+
+	Header http.Header
+	Body   []byte
+}
+
+// Handoff passes the event on for asynchronous processing, e.g. puts it
+// into a job queue. It is called while the sender waits for the response,
+// so it should not process the event itself.
+type Handoff func(ctx context.Context, e Event) error
+
+// Option is an option for Handler.
+type Option func(*handler)
+
+// MaxBody returns an option that sets the maximum body size in bytes.
+// Requests with larger bodies get 413. Default is 1 MiB.
+func MaxBody(n int64) Option {
+	return func(h *handler) {
+		h.maxBody = n
+	}
+}
+
+// Handler returns a handler that receives webhooks. It responds with:
+//
+//   - 202 if the event is verified and handed off;
+//   - 401 if the verification fails;
+//   - 413 if the body is too large;
+//   - 503 if the handoff fails, so the sender retries the delivery later.
+func Handler(v Verifier, handoff Handoff, opts ...Option) http.Handler {
+	h := &handler{
+		verifier: v,
+		handoff:  handoff,
+		maxBody:  1 << 20,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type handler struct {
+	verifier Verifier
+	handoff  Handoff
+	maxBody  int64
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ReadBody(req, h.maxBody)
+	if errors.Is(err, ErrBodyTooLarge) {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verifier.Verify(req, body); err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	e := Event{
+		ID:     deliveryID(req.Header),
+		Retry:  retryNum(req.Header),
+		Header: req.Header,
+		Body:   body,
+	}
+	if err := h.handoff(req.Context(), e); err != nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ErrBodyTooLarge is returned by ReadBody if the body exceeds the limit.
+var ErrBodyTooLarge = errors.New("body is too large")
+
+// ReadBody reads at most max bytes of the request body.
+// It returns ErrBodyTooLarge if the body is larger.
+func ReadBody(req *http.Request, max int64) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+func deliveryID(h http.Header) string {
+	for _, name := range []string{"X-GitHub-Delivery", "X-Request-ID"} {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func retryNum(h http.Header) int {
+	n, _ := strconv.Atoi(h.Get("X-Slack-Retry-Num"))
+	return n
+}
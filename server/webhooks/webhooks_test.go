@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	secret := []byte("secret")
+	var handedOff []Event
+	fail := false
+	handler := Handler(GitHub(secret), func(ctx context.Context, e Event) error {
+		if fail {
+			return errors.New("queue is full")
+		}
+		handedOff = append(handedOff, e)
+		return nil
+	}, MaxBody(16))
+
+	serve := func(body, sig string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", "sha256="+sig)
+		req.Header.Set("X-GitHub-Delivery", "d1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("accepts verified event", func(t *testing.T) {
+		if code := serve("payload", sign(secret, "payload")); code != http.StatusAccepted {
+			t.Fatalf("Expected status %d but got %d", http.StatusAccepted, code)
+		}
+		if len(handedOff) != 1 || handedOff[0].ID != "d1" || string(handedOff[0].Body) != "payload" {
+			t.Fatalf("Unexpected events: %+v", handedOff)
+		}
+	})
+
+	t.Run("rejects invalid signature", func(t *testing.T) {
+		if code := serve("payload", sign(secret, "other")); code != http.StatusUnauthorized {
+			t.Fatalf("Expected status %d but got %d", http.StatusUnauthorized, code)
+		}
+	})
+
+	t.Run("rejects large body", func(t *testing.T) {
+		body := strings.Repeat("x", 17)
+		if code := serve(body, sign(secret, body)); code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("Expected status %d but got %d", http.StatusRequestEntityTooLarge, code)
+		}
+	})
+
+	t.Run("asks to retry on failed handoff", func(t *testing.T) {
+		fail = true
+		defer func() { fail = false }()
+
+		if code := serve("payload", sign(secret, "payload")); code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status %d but got %d", http.StatusServiceUnavailable, code)
+		}
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hooks", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("Expected status %d but got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+// Package ws keeps WebSocket connections within the graceful shutdown
+// of the server package. It does not implement the protocol itself;
+// any WebSocket library can be plugged in with an Upgrader:
+//
+//	reg := ws.NewRegistry()
+//	upgrade := func(w http.ResponseWriter, req *http.Request) (ws.Conn, error) {
+//	    c, err := upgrader.Upgrade(w, req, nil) // e.g. gorilla/websocket
+//	    return gorillaConn{c}, err
+//	}
+//	http.Handle("/ws", reg.Handler(upgrade, func(ctx context.Context, c ws.Conn) {
+//	    // Read and write until ctx is done.
+//	}))
+//	srv := server.New(addr, nil, server.OnShutdown(reg.Shutdown))
+//
+// http.Server.Shutdown does not wait for upgraded (hijacked) connections,
+// so the registry does: on shutdown it cancels the connection contexts,
+// gives the handlers a grace period to finish, then closes the remaining
+// connections with the 1001 Going Away status.
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hypnoglow/x/server/serverctx"
+)
+
+// Close status codes, see RFC 6455.
+const (
+	CloseNormal    = 1000
+	CloseGoingAway = 1001
+)
+
+// ErrShutdown is the cause of the connection context cancellation
+// when the server begins to shut down.
+var ErrShutdown = errors.New("server is shutting down")
+
+// Conn is a WebSocket connection provided by a WebSocket library.
+type Conn interface {
+	// Close sends a close frame with the status code and reason,
+	// and closes the connection.
+	Close(code int, reason string) error
+}
+
+// Upgrader upgrades the HTTP connection to a WebSocket one.
+// On failure, it is expected to respond to the client itself.
+type Upgrader func(w http.ResponseWriter, req *http.Request) (Conn, error)
+
+// Option is an option for Registry.
+type Option func(*Registry)
+
+// GracePeriod returns an option that sets how long handlers have to finish
+// after their contexts are cancelled on shutdown, before the connections
+// are closed. Default is 5 seconds.
+func GracePeriod(d time.Duration) Option {
+	return func(r *Registry) {
+		r.grace = d
+	}
+}
+
+// Registry tracks WebSocket connections to shut them down gracefully.
+type Registry struct {
+	grace time.Duration
+
+	mx       sync.Mutex
+	conns    map[*conn]struct{}
+	shutdown bool
+	wg       sync.WaitGroup
+}
+
+type conn struct {
+	Conn
+	cancel context.CancelCauseFunc
+}
+
+// NewRegistry returns a new Registry.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		grace: time.Second * 5,
+		conns: make(map[*conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Handler returns a handler that upgrades the connection and calls fn.
+// The context passed to fn is cancelled when the server begins to shut
+// down, with ErrShutdown as the cause. The connection is closed when fn
+// returns.
+func (r *Registry) Handler(upgrade Upgrader, fn func(ctx context.Context, c Conn)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c, err := upgrade(w, req)
+		if err != nil {
+			return
+		}
+
+		ctx, cancel := context.WithCancelCause(req.Context())
+		defer cancel(nil)
+
+		entry := &conn{Conn: c, cancel: cancel}
+		if !r.add(entry) {
+			c.Close(CloseGoingAway, ErrShutdown.Error())
+			return
+		}
+		defer r.remove(entry)
+
+		go func() {
+			select {
+			case <-serverctx.Shutdown(req.Context()):
+				cancel(ErrShutdown)
+			case <-ctx.Done():
+			}
+		}()
+
+		fn(ctx, c)
+		c.Close(CloseNormal, "")
+	})
+}
+
+// Len returns the number of open connections.
+func (r *Registry) Len() int {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return len(r.conns)
+}
+
+// Shutdown cancels the connection contexts, waits for the grace period,
+// closes the remaining connections with the 1001 Going Away status and
+// waits until all handlers return or ctx is done.
+// New connections are refused after Shutdown is called.
+//
+// Shutdown fits server.OnShutdown.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.mx.Lock()
+	r.shutdown = true
+	for c := range r.conns {
+		c.cancel(ErrShutdown)
+	}
+	r.mx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	grace := time.NewTimer(r.grace)
+	defer grace.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-grace.C:
+	case <-ctx.Done():
+	}
+
+	r.mx.Lock()
+	for c := range r.conns {
+		c.Close(CloseGoingAway, ErrShutdown.Error())
+	}
+	r.mx.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Registry) add(c *conn) bool {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.shutdown {
+		return false
+	}
+	r.conns[c] = struct{}{}
+	r.wg.Add(1)
+	return true
+}
+
+func (r *Registry) remove(c *conn) {
+	r.mx.Lock()
+	delete(r.conns, c)
+	r.mx.Unlock()
+	r.wg.Done()
+}
@@ -0,0 +1,141 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeConn struct {
+	mx     sync.Mutex
+	codes  []int
+	closed chan struct{}
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{closed: make(chan struct{})}
+}
+
+func (c *fakeConn) Close(code int, reason string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if len(c.codes) == 0 {
+		close(c.closed)
+	}
+	c.codes = append(c.codes, code)
+	return nil
+}
+
+func (c *fakeConn) firstCode() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if len(c.codes) == 0 {
+		return 0
+	}
+	return c.codes[0]
+}
+
+func upgradeTo(c *fakeConn) Upgrader {
+	return func(w http.ResponseWriter, req *http.Request) (Conn, error) {
+		return c, nil
+	}
+}
+
+func serve(h http.Handler) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	return done
+}
+
+func waitLen(t *testing.T, r *Registry, n int) {
+	deadline := time.Now().Add(time.Second)
+	for r.Len() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d connections but got %d", n, r.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("cancels context on shutdown", func(t *testing.T) {
+		reg := NewRegistry()
+		c := newFakeConn()
+		cause := make(chan error, 1)
+		done := serve(reg.Handler(upgradeTo(c), func(ctx context.Context, _ Conn) {
+			<-ctx.Done()
+			cause <- context.Cause(ctx)
+		}))
+		waitLen(t, reg, 1)
+
+		if err := reg.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		<-done
+
+		if err := <-cause; !errors.Is(err, ErrShutdown) {
+			t.Fatalf("Expected cause %v but got %v", ErrShutdown, err)
+		}
+		if code := c.firstCode(); code != CloseNormal {
+			t.Fatalf("Expected close code %d but got %d", CloseNormal, code)
+		}
+	})
+
+	t.Run("closes stuck connections after grace period", func(t *testing.T) {
+		reg := NewRegistry(GracePeriod(time.Millisecond * 10))
+		c := newFakeConn()
+		serve(reg.Handler(upgradeTo(c), func(ctx context.Context, _ Conn) {
+			// Ignore ctx, like a handler blocked on read.
+			<-c.closed
+		}))
+		waitLen(t, reg, 1)
+
+		if err := reg.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if code := c.firstCode(); code != CloseGoingAway {
+			t.Fatalf("Expected close code %d but got %d", CloseGoingAway, code)
+		}
+	})
+
+	t.Run("refuses connections after shutdown", func(t *testing.T) {
+		reg := NewRegistry()
+		reg.Shutdown(context.Background())
+
+		c := newFakeConn()
+		called := false
+		<-serve(reg.Handler(upgradeTo(c), func(ctx context.Context, _ Conn) {
+			called = true
+		}))
+
+		if called {
+			t.Fatalf("Expected handler not to be called")
+		}
+		if code := c.firstCode(); code != CloseGoingAway {
+			t.Fatalf("Expected close code %d but got %d", CloseGoingAway, code)
+		}
+	})
+
+	t.Run("returns when ctx is done", func(t *testing.T) {
+		reg := NewRegistry(GracePeriod(time.Hour))
+		release := make(chan struct{})
+		defer close(release)
+		serve(reg.Handler(upgradeTo(newFakeConn()), func(ctx context.Context, _ Conn) {
+			<-release
+		}))
+		waitLen(t, reg, 1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		if err := reg.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected %v but got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package servertest
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_MaxConnections(t *testing.T) {
+	ts := StartTestServer(t, http.HandlerFunc(testHandler), server.MaxConnections(1))
+
+	// The first connection takes the only slot.
+	conn, err := net.Dial("tcp", ts.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The second one is queued in the backlog until the first one is closed.
+	client := &http.Client{Timeout: time.Millisecond * 200}
+	if _, err := client.Get(ts.URL); err == nil {
+		t.Fatalf("Expected request to time out while the limit is reached")
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for ts.AcceptStats().Open != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 1 open connection but got %d", ts.AcceptStats().Open)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	conn.Close()
+	if code, body := getStatus(t, ts.URL); code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d: %s", http.StatusOK, code, body)
+	}
+
+	if st := ts.AcceptStats(); st.Accepted < 2 {
+		t.Fatalf("Expected at least 2 accepted connections but got %+v", st)
+	}
+
+	ts.ShutdownWithin(time.Second * 5)
+}
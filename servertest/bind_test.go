@@ -0,0 +1,69 @@
+package servertest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/server/middleware"
+)
+
+func TestServer_Bind(t *testing.T) {
+	t.Run("Should serve bound handlers", func(t *testing.T) {
+		ops := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.WriteString(w, "ops")
+		})
+		ts := StartTestServer(t, http.HandlerFunc(testHandler),
+			server.Use(middleware.RequestID()),
+			server.Bind("127.0.0.1:0", ops),
+		)
+
+		addrs := ts.BindAddrs()
+		if len(addrs) != 1 {
+			t.Fatalf("Expected 1 bound address but got %v", addrs)
+		}
+
+		resp, err := http.Get("http://" + addrs[0].String())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "ops" {
+			t.Fatalf("Unexpected response body: %s", body)
+		}
+		if resp.Header.Get("X-Request-ID") != "" {
+			t.Fatalf("Expected bound handler not to get the server middlewares")
+		}
+
+		if body, err := getBody(ts.URL); err != nil || body != "Just testing!" {
+			t.Fatalf("Unexpected response: %s, %v", body, err)
+		}
+
+		ts.ShutdownWithin(time.Second * 5)
+		if _, err := net.Dial("tcp", addrs[0].String()); err == nil {
+			t.Fatalf("Expected bound handler to be shut down")
+		}
+	})
+
+	t.Run("Should fail to start if address is taken", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer ln.Close()
+
+		addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+		gsrv := server.New(addr, http.HandlerFunc(testHandler), server.Signals(),
+			server.Bind(ln.Addr().String(), http.NotFoundHandler()),
+		)
+		if err := gsrv.Start(); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package servertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/server/middleware"
+)
+
+func TestServer_ClientCerts(t *testing.T) {
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	certs := ts.TLS.Certificates
+	roots := ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	ts.Close()
+
+	ca, caKey := newTestCA(t)
+	clientCert := newTestClientCert(t, ca, caKey, "spiffe://example.org/billing")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+
+	addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+	auth := middleware.Auth(middleware.ClientCert(middleware.CertPrincipal), nil)
+	gsrv := server.New(addr, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p, _ := middleware.GetPrincipal(req.Context())
+		w.Header().Set("X-Principal", p.ID)
+	}),
+		server.Signals(),
+		server.TLSConfig(&tls.Config{Certificates: certs}),
+		server.ClientCerts(clientCAs, false),
+		server.Use(auth),
+	)
+	go gsrv.Start()
+	waitForListener(t, addr)
+	defer gsrv.Shutdown()
+
+	get := func(certs ...tls.Certificate) *http.Response {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+		resp, err := client.Get("https://" + addr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := get(clientCert)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Principal") != "spiffe://example.org/billing" {
+		t.Fatalf("Unexpected response: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	if resp := get(); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d without client certificate but got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return ca, key
+}
+
+func newTestClientCert(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, uri string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	u, _ := url.Parse(uri)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "billing", OrganizationalUnit: []string{"payments"}},
+		URIs:         []*url.URL{u},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
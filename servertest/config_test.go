@@ -0,0 +1,46 @@
+package servertest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/server/middleware"
+)
+
+func TestServer_Config(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	var log bytes.Buffer
+
+	gsrv := server.New(addr, http.HandlerFunc(testHandler),
+		server.Log(&log),
+		server.ShutdownTimeout(time.Second*3),
+		server.Use(middleware.RequestID(), middleware.Recover(&log)),
+	)
+
+	cfg := gsrv.Config()
+	if cfg.Addr != addr || cfg.Network != "tcp" || cfg.ShutdownTimeout != time.Second*3 {
+		t.Fatalf("Unexpected config: %+v", cfg)
+	}
+	if strings.Join(cfg.Middlewares, ",") != "middleware.RequestID,middleware.Recover" {
+		t.Fatalf("Unexpected middlewares: %v", cfg.Middlewares)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		gsrv.Start()
+		close(done)
+	}()
+	waitForListener(t, addr)
+	gsrv.Shutdown()
+	<-done
+
+	expected := "Server config: addr=" + addr + " network=tcp tls=false"
+	if !strings.Contains(log.String(), expected) {
+		t.Fatalf("Expected log to contain %q but got %q", expected, log.String())
+	}
+}
@@ -0,0 +1,90 @@
+package servertest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_DevMode(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	log := &syncBuffer{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=63072000")
+		w.Header().Set("Cache-Control", "max-age=3600")
+		io.WriteString(w, "Just testing!")
+	})
+
+	swaps := make(chan http.Handler)
+	gsrv := server.New(addr, handler, server.Log(log), server.DevMode(), server.SwapOn(swaps))
+	go gsrv.Start()
+	waitForListener(t, addr)
+	defer gsrv.Shutdown()
+
+	resp, err := http.Get("http://" + addr + "/page")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if hsts := resp.Header.Get("Strict-Transport-Security"); hsts != "" {
+		t.Fatalf("Expected no HSTS header but got %q", hsts)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-store" {
+		t.Fatalf("Expected Cache-Control to be %q but got %q", "no-store", cc)
+	}
+	if !strings.Contains(log.String(), "GET /page 200") {
+		t.Fatalf("Expected request to be logged but got %q", log.String())
+	}
+	if !strings.Contains(log.String(), "  dev_mode: true\n") {
+		t.Fatalf("Expected config banner to be logged but got %q", log.String())
+	}
+
+	if code, _ := getStatus(t, "http://"+addr+"/debug/pprof/"); code != http.StatusOK {
+		t.Fatalf("Expected pprof to be served but got status %d", code)
+	}
+
+	swaps <- http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "Swapped!")
+	})
+
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		body, err := getBody("http://" + addr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if body == "Swapped!" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected handler to be swapped")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mx  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buf.String()
+}
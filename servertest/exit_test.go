@@ -0,0 +1,75 @@
+package servertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestExitCode(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected int
+	}{
+		"clean":         {nil, server.ExitOK},
+		"serve error":   {errors.New("listen tcp :80: bind: permission denied"), server.ExitServeError},
+		"drain timeout": {fmt.Errorf("shutdown: %w", context.DeadlineExceeded), server.ExitDrainTimeout},
+		"forced":        {errors.Join(context.Canceled, server.ErrForced), server.ExitForced},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if code := server.ExitCode(tc.err); code != tc.expected {
+				t.Fatalf("Expected exit code %d but got %d", tc.expected, code)
+			}
+		})
+	}
+}
+
+func TestServer_ForcedShutdown(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	release := make(chan struct{})
+	defer close(release)
+	gsrv := server.New(addr, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}), server.ShutdownTimeout(time.Minute))
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- gsrv.Run(context.Background())
+	}()
+	waitForListener(t, addr)
+
+	go http.Get("http://" + addr)
+	deadline := time.Now().Add(time.Second * 5)
+	for gsrv.DrainStatus().InFlight == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected request to be in flight")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	gsrv.InjectSignal(syscall.SIGTERM)
+	for gsrv.DrainStatus().State != server.StateDraining {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected server to start draining")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	gsrv.InjectSignal(syscall.SIGTERM)
+
+	select {
+	case err := <-errc:
+		if code := server.ExitCode(err); code != server.ExitForced {
+			t.Fatalf("Expected exit code %d but got %d: %v", server.ExitForced, code, err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("Expected Run to return after the second signal")
+	}
+}
@@ -0,0 +1,58 @@
+package servertest
+
+import (
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_GCTuning(t *testing.T) {
+	defer debug.SetGCPercent(debug.SetGCPercent(100))
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(-1))
+
+	t.Run("applies and logs tuning", func(t *testing.T) {
+		os.Unsetenv("GOGC")
+		os.Unsetenv("GOMEMLIMIT")
+
+		var log syncBuffer
+		srv := StartTestServer(t, http.HandlerFunc(testHandler),
+			server.Log(&log),
+			server.GCPercent(200),
+			server.MemoryLimit(1<<30),
+			server.Ballast(1<<20),
+		)
+		srv.ShutdownWithin(time.Second)
+
+		if p := debug.SetGCPercent(100); p != 200 {
+			t.Fatalf("Expected GC percent to be %d but got %d", 200, p)
+		}
+		if l := debug.SetMemoryLimit(-1); l != 1<<30 {
+			t.Fatalf("Expected memory limit to be %d but got %d", 1<<30, l)
+		}
+		expected := "GC tuning: gogc=200 memory_limit=1073741824 ballast=1048576"
+		if !strings.Contains(log.String(), expected) {
+			t.Fatalf("Expected log to contain %q but got %q", expected, log.String())
+		}
+	})
+
+	t.Run("keeps GOGC set by operator", func(t *testing.T) {
+		os.Setenv("GOGC", "150")
+		defer os.Unsetenv("GOGC")
+
+		var log syncBuffer
+		srv := StartTestServer(t, http.HandlerFunc(testHandler), server.Log(&log), server.GCPercent(300))
+		srv.ShutdownWithin(time.Second)
+
+		if p := debug.SetGCPercent(100); p == 300 {
+			t.Fatalf("Expected GC percent not to be overridden")
+		}
+		if !strings.Contains(log.String(), "gogc=150 (from GOGC)") {
+			t.Fatalf("Unexpected log: %q", log.String())
+		}
+	})
+}
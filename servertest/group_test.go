@@ -0,0 +1,68 @@
+package servertest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestGroup(t *testing.T) {
+	handler := http.HandlerFunc(testHandler)
+
+	t.Run("Should run all members until context is cancelled", func(t *testing.T) {
+		addr1 := fmt.Sprintf(":%d", getFreePort())
+		addr2 := fmt.Sprintf(":%d", getFreePort())
+
+		g := server.NewGroup(server.GroupShutdownTimeout(time.Second))
+		g.Add(server.New(addr1, handler), server.New(addr2, handler))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- g.Run(ctx)
+		}()
+		waitForListener(t, addr1)
+		waitForListener(t, addr2)
+
+		cancel()
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Expected Run to return after context cancellation")
+		}
+	})
+
+	t.Run("Should shut down all members on first failure", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer ln.Close()
+
+		addr := fmt.Sprintf(":%d", getFreePort())
+		g := server.NewGroup()
+		g.Add(server.New(addr, handler), server.New(ln.Addr().String(), handler))
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- g.Run(context.Background())
+		}()
+
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Fatalf("Expected error")
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Expected Run to return after member failure")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package servertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_Health(t *testing.T) {
+	t.Run("Should report checks", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+		gsrv := server.New(addr, http.HandlerFunc(testHandler),
+			server.LivenessCheck("loop", func(ctx context.Context) error {
+				return nil
+			}),
+			server.ReadinessCheck("db", func(ctx context.Context) error {
+				return errors.New("connection refused")
+			}),
+		)
+		go gsrv.Start()
+		waitForListener(t, addr)
+		defer gsrv.Shutdown()
+
+		code, body := getStatus(t, "http://"+addr+"/healthz")
+		if code != http.StatusOK {
+			t.Fatalf("Expected liveness status %d but got %d: %s", http.StatusOK, code, body)
+		}
+
+		code, body = getStatus(t, "http://"+addr+"/readyz")
+		if code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected readiness status %d but got %d", http.StatusServiceUnavailable, code)
+		}
+		if !strings.Contains(body, "db: connection refused") {
+			t.Fatalf("Unexpected readiness body: %s", body)
+		}
+	})
+
+	t.Run("Should fail readiness during drain delay", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+		gsrv := server.New(addr, http.HandlerFunc(testHandler),
+			server.Health(),
+			server.DrainDelay(time.Millisecond*300),
+		)
+		go gsrv.Start()
+		waitForListener(t, addr)
+
+		if code, body := getStatus(t, "http://"+addr+"/readyz"); code != http.StatusOK {
+			t.Fatalf("Expected readiness status %d but got %d: %s", http.StatusOK, code, body)
+		}
+
+		shutdownDone := make(chan struct{})
+		go func() {
+			gsrv.Shutdown()
+			close(shutdownDone)
+		}()
+
+		deadline := time.Now().Add(time.Second * 5)
+		for gsrv.DrainStatus().State != server.StateDraining {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected server to start draining")
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		// The server still serves during the drain delay.
+		if code, _ := getStatus(t, "http://"+addr+"/readyz"); code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected readiness status %d but got %d", http.StatusServiceUnavailable, code)
+		}
+		if code, _ := getStatus(t, "http://"+addr); code != http.StatusOK {
+			t.Fatalf("Expected status %d but got %d", http.StatusOK, code)
+		}
+
+		<-shutdownDone
+	})
+}
+
+func getStatus(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return resp.StatusCode, string(body)
+}
@@ -0,0 +1,56 @@
+package servertest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/server/middleware"
+)
+
+func TestFromHTTPTest(t *testing.T) {
+	hs := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Millisecond * 100)
+		io.WriteString(w, "Just testing!")
+	}))
+	ts := FromHTTPTest(t, hs, server.Use(middleware.RequestID()))
+
+	if hs.URL == "" || hs.URL != ts.URL {
+		t.Fatalf("Expected httptest URL to be %q but got %q", ts.URL, hs.URL)
+	}
+	if ts.Addr().String() != hs.Listener.Addr().String() {
+		t.Fatalf("Expected server to listen @ %s but got %s", hs.Listener.Addr(), ts.Addr())
+	}
+
+	resp, err := http.Get(hs.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get(middleware.RequestIDHeader) == "" {
+		t.Fatalf("Expected middlewares to be applied")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, hs.URL, nil)
+	ts.AssertInFlightFinish(req)
+}
+
+func TestToHTTPTest(t *testing.T) {
+	ts := StartTestServer(t, http.HandlerFunc(testHandler))
+	hs := ToHTTPTest(ts)
+
+	if hs.URL != ts.URL || hs.Listener.Addr().String() != ts.Addr().String() {
+		t.Fatalf("Unexpected httptest server: %s %s", hs.URL, hs.Listener.Addr())
+	}
+
+	body, err := getBody(hs.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if body != "Just testing!" {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
@@ -0,0 +1,82 @@
+package servertest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_LameDuck(t *testing.T) {
+	t.Run("Should fail readiness and close connections", func(t *testing.T) {
+		ts := StartTestServer(t, http.HandlerFunc(testHandler), server.Health())
+
+		ts.LameDuck(0)
+		if st := ts.DrainStatus().State; st != server.StateLameDuck {
+			t.Fatalf("Expected state %q but got %q", server.StateLameDuck, st)
+		}
+
+		if code, _ := getStatus(t, ts.URL+"/readyz"); code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected readiness status %d but got %d", http.StatusServiceUnavailable, code)
+		}
+
+		resp, err := http.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status %d but got %d", http.StatusOK, resp.StatusCode)
+		}
+		if !resp.Close {
+			t.Fatalf("Expected connection to be closed")
+		}
+
+		ts.Resume()
+		if code, body := getStatus(t, ts.URL+"/readyz"); code != http.StatusOK {
+			t.Fatalf("Expected readiness status %d but got %d: %s", http.StatusOK, code, body)
+		}
+
+		ts.ShutdownWithin(time.Second * 5)
+	})
+
+	t.Run("Should resume after duration", func(t *testing.T) {
+		ts := StartTestServer(t, http.HandlerFunc(testHandler))
+
+		ts.LameDuck(time.Millisecond * 100)
+		deadline := time.Now().Add(time.Second * 5)
+		for ts.DrainStatus().State != server.StateServing {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected server to resume serving")
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	})
+
+	t.Run("Should be controlled on admin server", func(t *testing.T) {
+		adminAddr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+		ts := StartTestServer(t, http.HandlerFunc(testHandler), server.Admin(adminAddr))
+		waitForListener(t, adminAddr)
+
+		resp, err := http.Post("http://"+adminAddr+"/admin/lame-duck?duration=1h", "", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if st := ts.DrainStatus().State; st != server.StateLameDuck {
+			t.Fatalf("Expected state %q but got %q", server.StateLameDuck, st)
+		}
+
+		req, _ := http.NewRequest(http.MethodDelete, "http://"+adminAddr+"/admin/lame-duck", nil)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if st := ts.DrainStatus().State; st != server.StateServing {
+			t.Fatalf("Expected state %q but got %q", server.StateServing, st)
+		}
+	})
+}
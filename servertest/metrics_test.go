@@ -0,0 +1,68 @@
+package servertest
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	rec := &fakeRecorder{requests: make(map[string]int)}
+
+	gsrv := server.New(addr, http.HandlerFunc(testHandler), server.Metrics(rec))
+	go gsrv.Start()
+	waitForListener(t, addr)
+
+	if _, err := getBody("http://" + addr); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := gsrv.Shutdown(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	rec.mx.Lock()
+	defer rec.mx.Unlock()
+
+	if rec.requests["GET 200"] != 1 {
+		t.Fatalf("Expected one request to be recorded but got %v", rec.requests)
+	}
+	if rec.maxConns < 1 {
+		t.Fatalf("Expected active connections to be recorded")
+	}
+	if rec.shutdowns != 1 || rec.stats.DeadlineExceeded {
+		t.Fatalf("Unexpected shutdown stats: %d, %+v", rec.shutdowns, rec.stats)
+	}
+}
+
+type fakeRecorder struct {
+	mx        sync.Mutex
+	maxConns  int
+	requests  map[string]int
+	shutdowns int
+	stats     server.ShutdownStats
+}
+
+func (r *fakeRecorder) SetActiveConnections(n int) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if n > r.maxConns {
+		r.maxConns = n
+	}
+}
+
+func (r *fakeRecorder) IncRequests(method string, code int) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.requests[fmt.Sprintf("%s %d", method, code)]++
+}
+
+func (r *fakeRecorder) ObserveShutdown(stats server.ShutdownStats) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.shutdowns++
+	r.stats = stats
+}
@@ -0,0 +1,56 @@
+package servertest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/server/middleware"
+)
+
+func TestServer_PanicLimit(t *testing.T) {
+	addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+	var log syncBuffer
+	gsrv := server.New(addr, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("corrupted state")
+	}),
+		server.Log(&log),
+		server.Signals(),
+		server.Use(middleware.Recover(ioutil.Discard)),
+		server.PanicLimit(2, time.Minute),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- gsrv.Run(context.Background())
+	}()
+	waitForListener(t, addr)
+
+	for i := 0; i < 2; i++ {
+		getStatus(t, "http://"+addr)
+	}
+	select {
+	case <-done:
+		t.Fatalf("Expected server to keep running within the limit")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	getStatus(t, "http://"+addr)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("Expected server to stop after exceeding the limit")
+	}
+
+	if !strings.Contains(log.String(), "Panic limit exceeded") {
+		t.Fatalf("Unexpected log: %q", log.String())
+	}
+}
@@ -0,0 +1,91 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package servertest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_InheritListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer ln.Close()
+
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	// The server takes over the descriptor, so give it a copy.
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	os.Setenv(server.ListenerFDEnv, strconv.Itoa(fd))
+	defer os.Unsetenv(server.ListenerFDEnv)
+
+	gsrv := server.New(":0", http.HandlerFunc(testHandler), server.Signals())
+	go gsrv.Start()
+	defer gsrv.Shutdown()
+	<-gsrv.Ready()
+
+	if gsrv.Addr().String() != ln.Addr().String() {
+		t.Fatalf("Expected server to listen @ %s but got %s", ln.Addr(), gsrv.Addr())
+	}
+	if _, ok := os.LookupEnv(server.ListenerFDEnv); ok {
+		t.Fatalf("Expected %s to be unset", server.ListenerFDEnv)
+	}
+
+	body, err := getBody("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if body != "Just testing!" {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
+
+func TestServer_ReusePort(t *testing.T) {
+	addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+
+	first := server.New(addr, http.HandlerFunc(testHandler), server.Signals(), server.ReusePort())
+	second := server.New(addr, http.HandlerFunc(testHandler), server.Signals(), server.ReusePort())
+
+	errc := make(chan error, 2)
+	for _, gsrv := range []*server.Server{first, second} {
+		gsrv := gsrv
+		go func() { errc <- gsrv.Start() }()
+		select {
+		case <-gsrv.Ready():
+		case err := <-errc:
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	if err := first.Shutdown(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	body, err := getBody("http://" + addr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if body != "Just testing!" {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+
+	if err := second.Shutdown(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,87 @@
+package servertest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/server/middleware"
+)
+
+func TestServer_UseFor(t *testing.T) {
+	apiOnly := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-API", "true")
+			next.ServeHTTP(w, req)
+		})
+	}
+
+	srv := StartTestServer(t, http.HandlerFunc(testHandler),
+		server.Named("reqid", middleware.RequestID()),
+		server.UseFor("/api/", apiOnly),
+		server.Skip("/healthz", "reqid"),
+	)
+
+	tests := []struct {
+		path      string
+		requestID bool
+		api       bool
+	}{
+		{"/api/orders", true, true},
+		{"/apix", true, false},
+		{"/healthz", false, false},
+		{"/healthz/deep", true, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tc.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			resp.Body.Close()
+
+			if has := resp.Header.Get(middleware.RequestIDHeader) != ""; has != tc.requestID {
+				t.Fatalf("Expected request ID to be applied: %v, but got %v", tc.requestID, has)
+			}
+			if has := resp.Header.Get("X-API") != ""; has != tc.api {
+				t.Fatalf("Expected API middleware to be applied: %v, but got %v", tc.api, has)
+			}
+		})
+	}
+
+	if mws := strings.Join(srv.Config().Middlewares, ","); !strings.Contains(mws, "@/api/") {
+		t.Fatalf("Expected scoped middleware in config but got %s", mws)
+	}
+}
+
+func TestServer_Skip(t *testing.T) {
+	header := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set(name, "true")
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	srv := StartTestServer(t, http.HandlerFunc(testHandler),
+		server.Named("a", header("X-A")),
+		server.Use(header("X-B")),
+		server.Named("c", header("X-C")),
+		server.Skip("/healthz", "a"),
+	)
+
+	resp, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get("X-A") != "" {
+		t.Fatalf("Expected skipped middleware not to be applied")
+	}
+	if resp.Header.Get("X-B") == "" || resp.Header.Get("X-C") == "" {
+		t.Fatalf("Expected other instances of the middleware to be applied but got %v", resp.Header)
+	}
+}
@@ -0,0 +1,76 @@
+package servertest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_Run(t *testing.T) {
+	handler := http.HandlerFunc(testHandler)
+
+	t.Run("Should shut down when context is cancelled", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+		gsrv := server.New(addr, handler)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- gsrv.Run(ctx)
+		}()
+		waitForListener(t, addr)
+
+		body, err := getBody("http://" + addr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if body != "Just testing!" {
+			t.Fatalf("Unexpected response body: %s", body)
+		}
+
+		cancel()
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Expected Run to return after context cancellation")
+		}
+	})
+
+	t.Run("Should shut down on signal", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+		gsrv := server.New(addr, handler)
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- gsrv.Run(context.Background())
+		}()
+		waitForListener(t, addr)
+
+		gsrv.InjectSignal(syscall.SIGTERM)
+		if err := <-errc; err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	})
+
+	t.Run("Should return error when address is in use", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer ln.Close()
+
+		gsrv := server.New(ln.Addr().String(), handler)
+		if err := gsrv.Run(context.Background()); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
@@ -1,12 +1,16 @@
 package servertest
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/hypnoglow/x/server"
 )
@@ -18,23 +22,224 @@ func TestServer(t *testing.T) {
 	t.Run("Should execute standard flow", func(t *testing.T) {
 		gsrv := server.New(addr, handler)
 		go gsrv.Start()
+		waitForListener(t, addr)
 
 		go func() {
 			defer gsrv.Stop()
 
 			body, err := getBody("http://" + addr)
 			if err != nil {
-				t.Fatalf("Unexpected error: %s", err)
+				t.Errorf("Unexpected error: %s", err)
+				return
 			}
 
 			if body != "Just testing!" {
-				t.Fatalf("Unexpected response body: %s", string(body))
+				t.Errorf("Unexpected response body: %s", string(body))
 			}
 		}()
 
 		gsrv.Wait()
 		gsrv.Shutdown()
 	})
+
+	t.Run("Should stop on injected signal", func(t *testing.T) {
+		gsrv := server.New(addr, handler)
+		go gsrv.Start()
+		waitForListener(t, addr)
+
+		gsrv.InjectSignal(syscall.SIGTERM)
+
+		done := make(chan struct{})
+		go func() {
+			gsrv.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected Wait to return after injected signal")
+		}
+
+		gsrv.Shutdown()
+	})
+
+	t.Run("Should listen on the configured network", func(t *testing.T) {
+		addr4 := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+		gsrv := server.New(addr4, handler, server.Network("tcp4"))
+		go gsrv.Start()
+		waitForListener(t, addr4)
+
+		body, err := getBody("http://" + addr4)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if body != "Just testing!" {
+			t.Fatalf("Unexpected response body: %s", body)
+		}
+
+		gsrv.Stop()
+		gsrv.Shutdown()
+	})
+
+	t.Run("Should stop when listener cannot be created", func(t *testing.T) {
+		gsrv := server.New(addr, handler, server.Network("bogus"))
+		gsrv.Start()
+		gsrv.Wait()
+		gsrv.Shutdown()
+	})
+
+	t.Run("Should apply middlewares in order", func(t *testing.T) {
+		header := func(value string) func(http.Handler) http.Handler {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.Header().Add("X-Middleware", value)
+					next.ServeHTTP(w, req)
+				})
+			}
+		}
+
+		gsrv := server.New(addr, handler, server.Use(header("first"), header("second")))
+		go gsrv.Start()
+		waitForListener(t, addr)
+		defer gsrv.Shutdown()
+
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+
+		if values := resp.Header["X-Middleware"]; len(values) != 2 || values[0] != "first" {
+			t.Fatalf("Unexpected middleware headers: %v", values)
+		}
+	})
+
+	t.Run("Should not block on injected signal after stop", func(t *testing.T) {
+		gsrv := server.New(addr, handler)
+		gsrv.Stop()
+		gsrv.InjectSignal(syscall.SIGTERM)
+		gsrv.Wait()
+	})
+
+	t.Run("Should force shutdown on injected signal during drain", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+		release := make(chan struct{})
+		defer close(release)
+		gsrv := server.New(addr, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			<-release
+		}), server.ShutdownTimeout(time.Minute))
+		go gsrv.Start()
+		waitForListener(t, addr)
+
+		go getBody("http://" + addr)
+		deadline := time.Now().Add(time.Second * 5)
+		for gsrv.DrainStatus().InFlight == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected request to be in flight")
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		gsrv.Stop()
+		errc := make(chan error, 1)
+		go func() {
+			errc <- gsrv.Shutdown()
+		}()
+		for gsrv.DrainStatus().State != server.StateDraining {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected server to start draining")
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		gsrv.InjectSignal(syscall.SIGTERM)
+
+		select {
+		case err := <-errc:
+			if !errors.Is(err, server.ErrForced) {
+				t.Fatalf("Expected error %v but got %v", server.ErrForced, err)
+			}
+			if code := server.ExitCode(err); code != server.ExitForced {
+				t.Fatalf("Expected exit code %d but got %d", server.ExitForced, code)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Expected shutdown to be forced by the injected signal")
+		}
+	})
+}
+
+func TestServer_DrainStatus(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	adminAddr := fmt.Sprintf(":%d", getFreePort())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "Just testing!")
+	})
+
+	gsrv := server.New(addr, handler, server.Admin(adminAddr))
+	go gsrv.Start()
+	waitForListener(t, addr)
+	waitForListener(t, adminAddr)
+
+	status := getDrainStatus(t, adminAddr)
+	if status.State != server.StateServing {
+		t.Fatalf("Expected state to be %q but got %q", server.StateServing, status.State)
+	}
+
+	go getBody("http://" + addr)
+	<-started
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		gsrv.Shutdown()
+		close(shutdownDone)
+	}()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		status = getDrainStatus(t, adminAddr)
+		if status.State == server.StateDraining {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected server to start draining")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if status.InFlight != 1 {
+		t.Fatalf("Expected %d in-flight requests but got %d", 1, status.InFlight)
+	}
+	if status.OldestInFlight <= 0 {
+		t.Fatalf("Expected oldest in-flight age to be positive but got %v", status.OldestInFlight)
+	}
+	if status.RemainingDeadline <= 0 {
+		t.Fatalf("Expected remaining deadline to be positive but got %v", status.RemainingDeadline)
+	}
+
+	close(release)
+	<-shutdownDone
+
+	if status := gsrv.DrainStatus(); status.State != server.StateStopped || status.InFlight != 0 {
+		t.Fatalf("Unexpected drain status after shutdown: %+v", status)
+	}
+}
+
+func getDrainStatus(t *testing.T, addr string) server.DrainStatus {
+	body, err := getBody("http://" + addr + "/admin/drain-status")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var status server.DrainStatus
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return status
 }
 
 func testHandler(w http.ResponseWriter, req *http.Request) {
@@ -56,6 +261,19 @@ func getBody(url string) (string, error) {
 	return string(body), nil
 }
 
+func waitForListener(t *testing.T, addr string) {
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Fatalf("Server did not start listening @ %s", addr)
+}
+
 func getFreePort() int {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -69,3 +287,26 @@ func getFreePort() int {
 	defer l.Close()
 	return l.Addr().(*net.TCPAddr).Port
 }
+
+func TestServer_AdminHandle(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	adminAddr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+
+	gsrv := server.New(addr, http.HandlerFunc(testHandler),
+		server.Admin(adminAddr),
+		server.AdminHandle("/admin/custom", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.WriteString(w, "custom")
+		})),
+	)
+	go gsrv.Start()
+	defer gsrv.Shutdown()
+	waitForListener(t, adminAddr)
+
+	body, err := getBody("http://" + adminAddr + "/admin/custom")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if body != "custom" {
+		t.Fatalf("Expected body to be %q but got %q", "custom", body)
+	}
+}
@@ -0,0 +1,190 @@
+// Package servertest provides helpers to test servers built
+// with package server.
+//
+// Typical usage:
+//
+//	func TestAPI(t *testing.T) {
+//	    ts := servertest.StartTestServer(t, api.Handler())
+//	    resp, err := http.Get(ts.URL + "/orders")
+//	    ...
+//	}
+//
+// The server is gracefully shut down when the test completes.
+package servertest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+// Server is a server started for a test.
+type Server struct {
+	*server.Server
+
+	// URL is the base URL of the server, e.g. "http://127.0.0.1:41234".
+	URL string
+
+	t            testing.TB
+	started      chan error
+	shutdownOnce sync.Once
+	shutdownDone chan struct{}
+	shutdownErr  error
+}
+
+// StartTestServer starts a server with the handler on a free port
+// of the loopback interface and waits until it accepts connections.
+// The options are passed to server.New. The server does not react
+// to OS signals unless the options tell otherwise.
+//
+// The server is gracefully shut down on the test cleanup,
+// unless it has been shut down by the test.
+func StartTestServer(t testing.TB, handler http.Handler, opts ...server.Option) *Server {
+	t.Helper()
+
+	opts = append([]server.Option{server.Signals()}, opts...)
+	return start(t, server.New("127.0.0.1:0", handler, opts...))
+}
+
+// FromHTTPTest starts a server that serves the unstarted httptest server:
+// its Config, with the handler, and its Listener. It lets tests built
+// around httptest.NewUnstartedServer get the assertions of this package.
+// The options are passed to server.Wrap, as in StartTestServer.
+// The URL of hs is set to the URL of the returned server.
+//
+// Do not call Start or Close of hs; the server is shut down like the one
+// returned by StartTestServer.
+func FromHTTPTest(t testing.TB, hs *httptest.Server, opts ...server.Option) *Server {
+	t.Helper()
+
+	if hs.URL != "" {
+		t.Fatalf("servertest: httptest server is already started")
+	}
+
+	opts = append([]server.Option{server.Signals(), server.ServeOn(hs.Listener)}, opts...)
+	ts := start(t, server.Wrap(hs.Config, opts...))
+	hs.URL = ts.URL
+	return ts
+}
+
+// ToHTTPTest returns an httptest.Server describing the running server,
+// for helpers that take *httptest.Server to read its URL, Listener or
+// Config. Its Client method returns nil, and its Close method must not
+// be called; shut the server down with the methods of ts instead.
+func ToHTTPTest(ts *Server) *httptest.Server {
+	return &httptest.Server{
+		URL:      ts.URL,
+		Listener: ts.Listener(),
+		Config:   ts.HTTPServer(),
+	}
+}
+
+func start(t testing.TB, srv *server.Server) *Server {
+	t.Helper()
+
+	ts := &Server{
+		Server:       srv,
+		t:            t,
+		started:      make(chan error, 1),
+		shutdownDone: make(chan struct{}),
+	}
+
+	go func() {
+		ts.started <- ts.Start()
+	}()
+
+	select {
+	case <-ts.Ready():
+	case err := <-ts.started:
+		t.Fatalf("servertest: failed to start server: %v", err)
+	case <-time.After(time.Second * 5):
+		t.Fatalf("servertest: server did not start in 5s")
+	}
+
+	scheme := "http"
+	if ts.Config().TLS {
+		scheme = "https"
+	}
+	ts.URL = fmt.Sprintf("%s://%s", scheme, ts.Addr())
+
+	t.Cleanup(func() {
+		<-ts.startShutdown()
+	})
+
+	return ts
+}
+
+// ShutdownWithin gracefully shuts the server down and fails the test
+// if the shutdown returns an error or takes longer than d.
+func (ts *Server) ShutdownWithin(d time.Duration) {
+	ts.t.Helper()
+
+	select {
+	case <-ts.startShutdown():
+		if ts.shutdownErr != nil {
+			ts.t.Fatalf("servertest: shutdown failed: %v", ts.shutdownErr)
+		}
+	case <-time.After(d):
+		ts.t.Fatalf("servertest: shutdown did not complete in %s", d)
+	}
+}
+
+// AssertInFlightFinish sends the request to the server, shuts the server
+// down while the request is being handled, and fails the test if the
+// request does not complete with 200 OK or the shutdown fails.
+// The handler of the request must take long enough to be caught in flight.
+func (ts *Server) AssertInFlightFinish(req *http.Request) {
+	ts.t.Helper()
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		resc <- result{resp, err}
+	}()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for ts.DrainStatus().InFlight == 0 {
+		if time.Now().After(deadline) {
+			ts.t.Fatalf("servertest: request did not reach the server in 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	<-ts.startShutdown()
+	if ts.shutdownErr != nil {
+		ts.t.Fatalf("servertest: shutdown failed: %v", ts.shutdownErr)
+	}
+
+	res := <-resc
+	if res.err != nil {
+		ts.t.Fatalf("servertest: in-flight request failed: %v", res.err)
+	}
+	if res.resp.StatusCode != http.StatusOK {
+		ts.t.Fatalf("servertest: in-flight request completed with status %d", res.resp.StatusCode)
+	}
+}
+
+// startShutdown starts the shutdown, if it is not started yet,
+// and returns a channel that is closed when the shutdown completes.
+func (ts *Server) startShutdown() <-chan struct{} {
+	ts.shutdownOnce.Do(func() {
+		go func() {
+			ts.shutdownErr = ts.Shutdown()
+			<-ts.started
+			close(ts.shutdownDone)
+		}()
+	})
+	return ts.shutdownDone
+}
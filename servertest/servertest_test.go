@@ -0,0 +1,37 @@
+package servertest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStartTestServer(t *testing.T) {
+	t.Run("Should serve requests", func(t *testing.T) {
+		ts := StartTestServer(t, http.HandlerFunc(testHandler))
+
+		body, err := getBody(ts.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if body != "Just testing!" {
+			t.Fatalf("Unexpected response body: %s", body)
+		}
+	})
+
+	t.Run("Should shut down within deadline", func(t *testing.T) {
+		ts := StartTestServer(t, http.HandlerFunc(testHandler))
+		ts.ShutdownWithin(time.Second)
+	})
+
+	t.Run("Should finish in-flight requests", func(t *testing.T) {
+		ts := StartTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			time.Sleep(time.Millisecond * 100)
+			io.WriteString(w, "Just testing!")
+		}))
+
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		ts.AssertInFlightFinish(req)
+	})
+}
@@ -0,0 +1,69 @@
+package servertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_Shutdown(t *testing.T) {
+	t.Run("Should run hooks in order and return their errors", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+		hookErr := errors.New("hook failed")
+
+		var calls []string
+		gsrv := server.New(addr, http.HandlerFunc(testHandler),
+			server.OnShutdown(func(ctx context.Context) error {
+				calls = append(calls, "first")
+				return nil
+			}),
+			server.OnShutdown(func(ctx context.Context) error {
+				calls = append(calls, "second")
+				if _, ok := ctx.Deadline(); !ok {
+					t.Errorf("Expected hook context to have a deadline")
+				}
+				return hookErr
+			}),
+		)
+		go gsrv.Start()
+		waitForListener(t, addr)
+
+		err := gsrv.Shutdown()
+		if !errors.Is(err, hookErr) {
+			t.Fatalf("Expected error %v but got %v", hookErr, err)
+		}
+		if !reflect.DeepEqual(calls, []string{"first", "second"}) {
+			t.Fatalf("Unexpected hook calls: %v", calls)
+		}
+	})
+
+	t.Run("Should respect shutdown timeout", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			close(started)
+			<-release
+		})
+
+		gsrv := server.New(addr, handler, server.ShutdownTimeout(time.Millisecond*50))
+		go gsrv.Start()
+		waitForListener(t, addr)
+
+		go getBody("http://" + addr)
+		<-started
+
+		err := gsrv.Shutdown()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected error %v but got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
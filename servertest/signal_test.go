@@ -0,0 +1,58 @@
+package servertest
+
+import (
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_Signals(t *testing.T) {
+	handler := http.HandlerFunc(testHandler)
+
+	t.Run("Should report the stop signal", func(t *testing.T) {
+		addr := fmt.Sprintf(":%d", getFreePort())
+		gsrv := server.New(addr, handler, server.Signals(syscall.SIGHUP))
+		go gsrv.Start()
+		waitForListener(t, addr)
+
+		gsrv.InjectSignal(syscall.SIGHUP)
+		gsrv.Wait()
+		gsrv.Shutdown()
+
+		if sig := gsrv.StopSignal(); sig != syscall.SIGHUP {
+			t.Fatalf("Expected stop signal to be %v but got %v", syscall.SIGHUP, sig)
+		}
+	})
+
+	t.Run("Should not report a signal when stopped manually", func(t *testing.T) {
+		gsrv := server.New(fmt.Sprintf(":%d", getFreePort()), handler)
+		gsrv.Stop()
+		gsrv.Wait()
+
+		if sig := gsrv.StopSignal(); sig != nil {
+			t.Fatalf("Expected no stop signal but got %v", sig)
+		}
+	})
+
+	t.Run("Should stop on trigger", func(t *testing.T) {
+		trigger := make(chan struct{})
+		gsrv := server.New(fmt.Sprintf(":%d", getFreePort()), handler, server.StopOn(trigger))
+
+		done := make(chan struct{})
+		go func() {
+			gsrv.Wait()
+			close(done)
+		}()
+
+		close(trigger)
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected Wait to return after trigger")
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package servertest
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+	"github.com/hypnoglow/x/server/sse"
+)
+
+func TestSSE_Shutdown(t *testing.T) {
+	handler := sse.Handler(func(ctx context.Context, w *sse.Writer) {
+		w.Send(sse.Event{Data: "hello"})
+		<-ctx.Done()
+		if context.Cause(ctx) == sse.ErrShutdown {
+			w.Send(sse.Event{Event: "shutdown", Retry: time.Millisecond * 100})
+		}
+	})
+	srv := StartTestServer(t, handler, server.ShutdownTimeout(time.Second*5))
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	if line, _ := r.ReadString('\n'); line != "data: hello\n" {
+		t.Fatalf("Unexpected line: %q", line)
+	}
+
+	srv.ShutdownWithin(time.Second)
+
+	var rest []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		rest = append(rest, line)
+	}
+	if len(rest) < 2 || rest[1] != "event: shutdown\n" {
+		t.Fatalf("Expected shutdown event but got %q", rest)
+	}
+}
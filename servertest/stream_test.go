@@ -0,0 +1,84 @@
+package servertest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestEarlyHints(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		server.EarlyHints(w, "</style.css>; rel=preload; as=style")
+		io.WriteString(w, "Just testing!")
+	})
+
+	gsrv := server.New(addr, handler)
+	go gsrv.Start()
+	waitForListener(t, addr)
+	defer gsrv.Shutdown()
+
+	var hints []int
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			hints = append(hints, code)
+			return nil
+		},
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr, nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if len(hints) != 1 || hints[0] != http.StatusEarlyHints {
+		t.Fatalf("Expected one 103 response but got %v", hints)
+	}
+	if resp.Header.Get("Link") == "" {
+		t.Fatalf("Expected final response to contain Link header")
+	}
+}
+
+func TestFlushWriter(t *testing.T) {
+	addr := fmt.Sprintf(":%d", getFreePort())
+	next := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fw := server.FlushWriter(w)
+		io.WriteString(fw, "first\n")
+		<-next
+		io.WriteString(fw, "second\n")
+	})
+
+	gsrv := server.New(addr, handler)
+	go gsrv.Start()
+	waitForListener(t, addr)
+	defer gsrv.Shutdown()
+
+	resp, err := http.Get("http://" + addr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	// The first line must arrive before the handler is unblocked.
+	r := bufio.NewReader(resp.Body)
+	line, err := r.ReadString('\n')
+	if err != nil || line != "first\n" {
+		t.Fatalf("Expected first line to be streamed but got %q, %v", line, err)
+	}
+	close(next)
+
+	line, _ = r.ReadString('\n')
+	if line != "second\n" {
+		t.Fatalf("Expected second line but got %q", line)
+	}
+}
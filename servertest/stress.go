@@ -0,0 +1,189 @@
+package servertest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+// StressConfig configures Stress.
+type StressConfig struct {
+	// Concurrency is the number of clients sending requests
+	// in parallel. Default is 16.
+	Concurrency int
+
+	// Cycles is the number of server instances started one after another.
+	// Default is 5.
+	Cycles int
+
+	// Uptime is how long each instance serves before it is shut down.
+	// Default is 200ms.
+	Uptime time.Duration
+
+	// Request returns a request to the server at the base URL.
+	// Requests must be replayable, e.g. GET, so that the client can
+	// resend them over a new connection when the server closes an idle
+	// one. Default is GET of the base URL.
+	Request func(url string) (*http.Request, error)
+
+	// Options are passed to server.New for every instance.
+	Options []server.Option
+}
+
+// StressReport is the result of Stress.
+type StressReport struct {
+	// Requests is the number of requests completed without error.
+	Requests int
+
+	// Refused is the number of requests that were resent because
+	// the connection was refused, i.e. they never reached a server.
+	Refused int
+
+	// Dropped is the number of requests that reached a server and failed,
+	// or were responded with a 5xx status.
+	Dropped int
+
+	// Errors lists the first errors of the dropped requests.
+	Errors []error
+}
+
+// maxStressErrors is the number of errors kept in StressReport.
+const maxStressErrors = 10
+
+// Stress hammers servers serving the handler with concurrent requests,
+// while repeatedly starting a fresh instance and gracefully shutting
+// the previous one down, like a rolling restart does. Each instance
+// listens on its own port; clients switch to the new instance as soon as
+// it is ready, and the old one drains the requests it has accepted.
+//
+// Stress fails the test if any request is dropped during the drains
+// or if any shutdown fails. It can be used both to validate the drain
+// of package server and the middleware and handlers of an app:
+//
+//	func TestAPI_Drain(t *testing.T) {
+//	    report := servertest.Stress(t, api.Handler(), servertest.StressConfig{})
+//	    t.Logf("%d requests served", report.Requests)
+//	}
+func Stress(t testing.TB, handler http.Handler, cfg StressConfig) StressReport {
+	t.Helper()
+
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 16
+	}
+	if cfg.Cycles <= 0 {
+		cfg.Cycles = 5
+	}
+	if cfg.Uptime <= 0 {
+		cfg.Uptime = time.Millisecond * 200
+	}
+	if cfg.Request == nil {
+		cfg.Request = func(url string) (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+	}
+
+	opts := append([]server.Option{server.Signals()}, cfg.Options...)
+	ts := start(t, server.New("127.0.0.1:0", handler, opts...))
+
+	var url atomic.Value
+	url.Store(ts.URL)
+
+	transport := &http.Transport{MaxIdleConnsPerHost: cfg.Concurrency}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: time.Second * 10}
+
+	var (
+		mx     sync.Mutex
+		report StressReport
+		done   = make(chan struct{})
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				refused, err := stressRequest(client, cfg.Request, url.Load().(string))
+				mx.Lock()
+				switch {
+				case refused:
+					report.Refused++
+				case err != nil:
+					report.Dropped++
+					if len(report.Errors) < maxStressErrors {
+						report.Errors = append(report.Errors, err)
+					}
+				default:
+					report.Requests++
+				}
+				mx.Unlock()
+			}
+		}()
+	}
+
+	for i := 0; i < cfg.Cycles; i++ {
+		time.Sleep(cfg.Uptime)
+
+		prev := ts
+		if i < cfg.Cycles-1 {
+			ts = start(t, server.New("127.0.0.1:0", handler, opts...))
+			url.Store(ts.URL)
+		}
+		<-prev.startShutdown()
+		if prev.shutdownErr != nil {
+			t.Errorf("servertest: shutdown of %s failed: %v", prev.URL, prev.shutdownErr)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	if report.Dropped > 0 {
+		t.Errorf("servertest: %d of %d requests dropped, first errors: %v",
+			report.Dropped, report.Requests+report.Dropped, report.Errors)
+	}
+	return report
+}
+
+// stressRequest sends a request to the server at url.
+// It reports whether the connection was refused, otherwise it returns
+// the error of a request that reached the server.
+func stressRequest(client *http.Client, newRequest func(string) (*http.Request, error), url string) (bool, error) {
+	req, err := newRequest(url)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true, nil
+		}
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return false, err
+	}
+	if resp.StatusCode >= 500 {
+		return false, fmt.Errorf("%s %s: status %d", req.Method, req.URL, resp.StatusCode)
+	}
+	return false, nil
+}
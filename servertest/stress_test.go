@@ -0,0 +1,26 @@
+package servertest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStress(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Millisecond * 5)
+		testHandler(w, req)
+	})
+
+	report := Stress(t, handler, StressConfig{
+		Concurrency: 8,
+		Cycles:      4,
+		Uptime:      time.Millisecond * 100,
+	})
+	if report.Requests == 0 {
+		t.Fatalf("Expected requests to be served but got none")
+	}
+	if report.Dropped != 0 {
+		t.Fatalf("Expected no dropped requests but got %d: %v", report.Dropped, report.Errors)
+	}
+}
@@ -0,0 +1,93 @@
+package servertest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/env"
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_SessionTickets(t *testing.T) {
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	certs := ts.TLS.Certificates
+	roots := ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	ts.Close()
+
+	os.Setenv("TLS_TICKET_KEYS", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=,AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=")
+	defer os.Unsetenv("TLS_TICKET_KEYS")
+	keys := env.MustParse[server.SessionTicketKeys]("TLS_TICKET_KEYS")
+	if len(keys) != 2 || keys[1][0] != 1 {
+		t.Fatalf("Unexpected keys: %v", keys)
+	}
+
+	start := func(opts ...server.Option) *server.Server {
+		addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+		opts = append([]server.Option{server.TLSConfig(&tls.Config{Certificates: certs})}, opts...)
+		gsrv := server.New(addr, http.HandlerFunc(testHandler), opts...)
+		go gsrv.Start()
+		waitForListener(t, addr)
+		t.Cleanup(func() { gsrv.Shutdown() })
+		return gsrv
+	}
+
+	// resumed connects to the server with the cache and reports
+	// whether the session was resumed.
+	resumed := func(gsrv *server.Server, cache tls.ClientSessionCache) bool {
+		conn, err := tls.Dial("tcp", gsrv.Addr().String(), &tls.Config{
+			RootCAs:            roots,
+			ServerName:         "example.com",
+			ClientSessionCache: cache,
+			MaxVersion:         tls.VersionTLS12,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().DidResume
+	}
+
+	t.Run("Should resume sessions on replicas with shared keys", func(t *testing.T) {
+		first := start(server.SessionTickets(keys))
+		second := start(server.SessionTickets(keys))
+
+		cache := tls.NewLRUClientSessionCache(1)
+		if resumed(first, cache) {
+			t.Fatalf("Expected the first session to be new")
+		}
+		if !resumed(second, cache) {
+			t.Fatalf("Expected the session to be resumed on the replica")
+		}
+	})
+
+	t.Run("Should not resume sessions after keys are replaced", func(t *testing.T) {
+		gsrv := start(server.SessionTickets(keys))
+
+		cache := tls.NewLRUClientSessionCache(1)
+		resumed(gsrv, cache)
+		gsrv.SetSessionTicketKeys(server.SessionTicketKeys{{2}})
+		if resumed(gsrv, cache) {
+			t.Fatalf("Expected the session not to be resumed with replaced keys")
+		}
+	})
+
+	t.Run("Should not resume sessions on replicas with rotated keys", func(t *testing.T) {
+		first := start(server.SessionTicketRotation(time.Hour))
+		second := start(server.SessionTicketRotation(time.Hour))
+
+		cache := tls.NewLRUClientSessionCache(1)
+		resumed(first, cache)
+		if !resumed(first, cache) {
+			t.Fatalf("Expected the session to be resumed on the same server")
+		}
+		if resumed(second, cache) {
+			t.Fatalf("Expected the session not to be resumed on a replica with own keys")
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package servertest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hypnoglow/x/server"
+)
+
+func TestServer_TLS(t *testing.T) {
+	// httptest provides a certificate valid for 127.0.0.1
+	// and a client that trusts it.
+	ts := httptest.NewUnstartedServer(nil)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	certs := ts.TLS.Certificates
+	client := ts.Client()
+	ts.Close()
+
+	t.Run("Should serve HTTPS", func(t *testing.T) {
+		addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+		gsrv := server.New(addr, http.HandlerFunc(testHandler), server.TLSConfig(&tls.Config{Certificates: certs}))
+		go gsrv.Start()
+		waitForListener(t, addr)
+		defer gsrv.Shutdown()
+
+		resp, err := client.Get("https://" + addr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		if string(body) != "Just testing!" {
+			t.Fatalf("Unexpected response body: %s", body)
+		}
+	})
+
+	t.Run("Should serve ACME challenges", func(t *testing.T) {
+		addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+		challengeAddr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+		m := fakeCertManager{cfg: &tls.Config{Certificates: certs}}
+
+		gsrv := server.New(addr, http.HandlerFunc(testHandler), server.AutoCert(m, challengeAddr))
+		go gsrv.Start()
+		waitForListener(t, addr)
+		waitForListener(t, challengeAddr)
+		defer gsrv.Shutdown()
+
+		body, err := getBody("http://" + challengeAddr + "/.well-known/acme-challenge/token")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if body != "challenge" {
+			t.Fatalf("Unexpected response body: %s", body)
+		}
+
+		resp, err := client.Get("https://" + addr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+	})
+}
+
+func TestServer_HTTP2Drain(t *testing.T) {
+	ts := httptest.NewUnstartedServer(nil)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	certs := ts.TLS.Certificates
+	client := ts.Client()
+	ts.Close()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", getFreePort())
+	started := make(chan string)
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		started <- req.Proto
+		<-release
+	})
+
+	gsrv := server.New(addr, handler, server.TLSConfig(&tls.Config{Certificates: certs}))
+	go gsrv.Start()
+	waitForListener(t, addr)
+
+	go func() {
+		resp, err := client.Get("https://" + addr)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	if proto := <-started; proto != "HTTP/2.0" {
+		t.Fatalf("Expected request over HTTP/2.0 but got %s", proto)
+	}
+
+	shutdownDone := make(chan error)
+	go func() {
+		shutdownDone <- gsrv.Shutdown()
+	}()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for gsrv.DrainStatus().State != server.StateDraining {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected server to start draining")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	status := gsrv.DrainStatus()
+	if status.InFlightByProtocol["HTTP/2.0"] != 1 {
+		t.Fatalf("Expected one in-flight HTTP/2.0 request but got %v", status.InFlightByProtocol)
+	}
+
+	close(release)
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+type fakeCertManager struct {
+	cfg *tls.Config
+}
+
+func (m fakeCertManager) TLSConfig() *tls.Config {
+	return m.cfg
+}
+
+func (m fakeCertManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "challenge")
+	})
+}